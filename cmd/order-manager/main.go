@@ -10,7 +10,6 @@ import (
 
 	"github.com/ivan-salazar14/nexus-order-manager/internal/application"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
-	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
 	exchange "github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/http"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/messaging"
@@ -56,35 +55,65 @@ func main() {
 
 	if useMockKafka {
 		logger.Info("Using mock Kafka pool for development")
-		kafkaPool = messaging.NewMockKafkaPool(&cfg.Kafka, logger)
+		kafkaPool, err = messaging.NewMockKafkaPool(&cfg.Kafka, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize mock Kafka pool", zap.Error(err))
+		}
 	} else {
-		kafkaPool = messaging.NewKafkaPool(&cfg.Kafka, logger)
+		kafkaPool, err = messaging.NewKafkaPool(&cfg.Kafka, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize Kafka pool", zap.Error(err))
+		}
 		if err := kafkaPool.EnsureTopicsExist(); err != nil {
 			logger.Warn("Failed to ensure Kafka topics exist", zap.Error(err))
 		}
 	}
 	defer kafkaPool.Close()
 
-	// Initialize Binance client
-	binanceClient := exchange.NewBinanceTestnetClient(
-		cfg.Binance.Testnet.APIKey,
-		cfg.Binance.Testnet.APISecret,
-	)
+	// Initialize exchange client for the configured venue
+	exchangeClient, err := exchange.New(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize exchange client", zap.Error(err))
+	}
+	logger.Info("Using exchange venue", zap.String("venue", cfg.Exchange.VenueName()))
+
+	// Initialize pre-trade risk checks
+	riskEngine := application.NewRiskEngine(cfg.Risk, exchangeClient)
 
 	// Initialize trading orchestrator
 	orchestrator := application.NewTradingOrchestrator(
 		repo,
-		binanceClient,
+		exchangeClient,
 		kafkaPool,
+		riskEngine,
 		logger,
 		3, // worker pool size
+		cfg.Idempotency.Retention(),
 	)
 	defer orchestrator.Stop()
 
 	// Start background processes
-	orderChan := make(chan *domain.Order, 100)
+	orderChan := make(chan application.OrderJob, 100)
+	orchestrator.StartKillSwitchWatch(cfg.KillSwitch.PollInterval())
 	orchestrator.StartWorkerPool(orderChan)
-	orchestrator.StartOutboxRelay(cfg.Outbox.PollInterval())
+	if cfg.KillSwitch.Engaged {
+		if _, err := orchestrator.EngageKillSwitch(context.Background(), "engaged by configuration", "config", cfg.KillSwitch.CancelOpenOrders); err != nil {
+			logger.Error("Failed to record kill switch engagement", zap.Error(err))
+		}
+	}
+	orchestrator.StartOutboxRelay(cfg.Outbox, cfg.Kafka.Topics)
+	if cfg.Outbox.Janitor.RetentionHours > 0 {
+		orchestrator.StartOutboxJanitor(cfg.Outbox.Janitor)
+	}
+	kafkaPool.ConsumeOrderEvents(orchestrator.DispatchOrder)
+	orchestrator.StartExecutionStream()
+	orchestrator.StartIdempotencyKeyPurge(time.Hour)
+	orchestrator.StartSymbolRefresh(cfg.SymbolInfo.RefreshInterval())
+	orchestrator.StartReconciler(
+		cfg.Reconciler.Interval(),
+		cfg.Reconciler.StaleAfter(),
+		cfg.Reconciler.BatchSize,
+	)
 
 	// Initialize HTTP server (infrastructure layer)
 	httpServer := http.NewHTTPServer(
@@ -92,6 +121,7 @@ func main() {
 		logger,
 		orchestrator,
 		repo,
+		kafkaPool,
 	)
 
 	// Start HTTP server
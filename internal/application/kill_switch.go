@@ -0,0 +1,286 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/persistence"
+	"go.uber.org/zap"
+)
+
+// killSwitchBatchSize is how many open orders are loaded at a time for cancellation
+const killSwitchBatchSize = 100
+
+// KillSwitchState is the current state of the kill switch
+type KillSwitchState struct {
+	Engaged bool      `json:"engaged"`
+	Reason  string    `json:"reason,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// newKillSwitchState converts the stored kill switch
+func newKillSwitchState(stored *domain.KillSwitch) KillSwitchState {
+	return KillSwitchState{
+		Engaged: stored.Engaged,
+		Reason:  stored.Reason,
+		Source:  stored.Source,
+		Since:   stored.UpdatedAt,
+	}
+}
+
+// killSwitch caches the shared kill switch state on this instance. released is
+// closed while trading is allowed, so halted workers can wait on it.
+type killSwitch struct {
+	mu       sync.Mutex
+	state    KillSwitchState
+	released chan struct{}
+}
+
+// newKillSwitch creates a released kill switch
+func newKillSwitch() *killSwitch {
+	released := make(chan struct{})
+	close(released)
+	return &killSwitch{
+		state:    KillSwitchState{Since: time.Now()},
+		released: released,
+	}
+}
+
+// set stores the latest known state and reports whether it engaged or released the switch
+func (k *killSwitch) set(state KillSwitchState) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	changed := k.state.Engaged != state.Engaged
+	if changed && state.Engaged {
+		k.released = make(chan struct{})
+	} else if changed {
+		close(k.released)
+	}
+	k.state = state
+	return changed
+}
+
+// current returns the state and the channel closed once trading is allowed
+func (k *killSwitch) current() (KillSwitchState, <-chan struct{}) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.state, k.released
+}
+
+// KillSwitch returns the kill switch state shared by every instance
+func (to *TradingOrchestrator) KillSwitch(ctx context.Context) (KillSwitchState, error) {
+	stored, err := to.repo.GetKillSwitch(ctx)
+	if err != nil {
+		return KillSwitchState{}, fmt.Errorf("failed to get kill switch: %w", err)
+	}
+
+	state := newKillSwitchState(stored)
+	if to.halt.set(state) {
+		to.logger.Warn("Kill switch changed",
+			zap.Bool("engaged", state.Engaged),
+			zap.String("reason", state.Reason),
+			zap.String("source", state.Source),
+		)
+	}
+	return state, nil
+}
+
+// StartKillSwitchWatch loads the shared kill switch state and re-reads it every
+// interval, so workers on this instance hold and resume orders when the switch is
+// toggled through any instance
+func (to *TradingOrchestrator) StartKillSwitchWatch(interval time.Duration) {
+	if _, err := to.KillSwitch(to.ctx); err != nil {
+		to.logger.Error("Failed to load kill switch", zap.Error(err))
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := to.KillSwitch(to.ctx); err != nil {
+					to.logger.Error("Failed to refresh kill switch", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// EngageKillSwitch halts trading on every instance: new orders are rejected and
+// the worker pools stop placing orders until the switch is released. With
+// cancelOpenOrders every open order is cancelled in the background, on the
+// exchange and in the queue. The switch and its audit event are written together.
+func (to *TradingOrchestrator) EngageKillSwitch(ctx context.Context, reason, source string, cancelOpenOrders bool) (KillSwitchState, error) {
+	state, err := to.toggleKillSwitch(ctx, true, reason, source)
+	if err != nil {
+		return state, err
+	}
+
+	if cancelOpenOrders {
+		to.wg.Add(1)
+		go func() {
+			defer to.wg.Done()
+			to.cancelOpenOrders(to.ctx)
+		}()
+	}
+	return state, nil
+}
+
+// ReleaseKillSwitch resumes trading on every instance. The switch and its audit
+// event are written together, so trading only resumes once the release is on record.
+func (to *TradingOrchestrator) ReleaseKillSwitch(ctx context.Context, reason, source string) (KillSwitchState, error) {
+	return to.toggleKillSwitch(ctx, false, reason, source)
+}
+
+// toggleKillSwitch stores the engaged state with the audit event of the toggle.
+// Setting the state the switch is already in changes nothing.
+func (to *TradingOrchestrator) toggleKillSwitch(ctx context.Context, engaged bool, reason, source string) (KillSwitchState, error) {
+	var stored *domain.KillSwitch
+	err := to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		var err error
+		stored, err = uow.GetKillSwitchForUpdate()
+		if err != nil {
+			return fmt.Errorf("failed to get kill switch: %w", err)
+		}
+		if stored.Engaged == engaged {
+			return nil
+		}
+
+		stored.Engaged = engaged
+		stored.Reason = reason
+		stored.Source = source
+		stored.UpdatedAt = time.Now()
+		if err := uow.SaveKillSwitch(stored); err != nil {
+			return fmt.Errorf("failed to save kill switch: %w", err)
+		}
+
+		eventType := domain.EventKillSwitchReleased
+		if engaged {
+			eventType = domain.EventKillSwitchEngaged
+		}
+		return appendOrderEvent(uow, &domain.OutboxEvent{
+			Aggregate:   "KillSwitch",
+			AggregateID: domain.KillSwitchID,
+			EventType:   eventType,
+			Payload: mustMarshal(domain.KillSwitchToggledV1{
+				Reason:    reason,
+				Source:    source,
+				ToggledAt: stored.UpdatedAt,
+			}),
+			SchemaVersion: domain.EventSchemaVersion,
+			Processed:     false,
+		})
+	})
+	if err != nil {
+		state, _ := to.halt.current()
+		return state, fmt.Errorf("failed to record kill switch change: %w", err)
+	}
+
+	state := newKillSwitchState(stored)
+	if !to.halt.set(state) {
+		return state, nil
+	}
+	if engaged {
+		to.logger.Warn("Kill switch engaged, trading halted",
+			zap.String("reason", reason),
+			zap.String("source", source),
+		)
+	} else {
+		to.logger.Info("Kill switch released, trading resumed",
+			zap.String("reason", reason),
+			zap.String("source", source),
+		)
+	}
+	return state, nil
+}
+
+// processWhenTrading processes an order, holding it while the kill switch is
+// engaged. The claim re-checks the shared switch, so an order is not placed when
+// another instance engaged it since this instance last read it.
+func (to *TradingOrchestrator) processWhenTrading(orderID string) error {
+	for {
+		if !to.waitForTrading() {
+			return to.ctx.Err()
+		}
+
+		err := to.ProcessOrder(to.ctx, orderID)
+		if !errors.Is(err, domain.ErrTradingHalted) {
+			return err
+		}
+		if _, err := to.KillSwitch(to.ctx); err != nil {
+			// Hold anyway; the watch releases the order once it can read the switch again
+			to.halt.set(KillSwitchState{Engaged: true, Since: time.Now()})
+		}
+	}
+}
+
+// waitForTrading blocks while the kill switch is engaged on this instance. It
+// returns false if the orchestrator is shutting down.
+func (to *TradingOrchestrator) waitForTrading() bool {
+	_, released := to.halt.current()
+	select {
+	case <-released:
+		return true
+	default:
+	}
+
+	select {
+	case <-released:
+		return true
+	case <-to.ctx.Done():
+		return false
+	}
+}
+
+// cancelOpenOrders cancels every order that is pending, executing or partially
+// filled. Orders that fail to cancel are logged and left as they are.
+func (to *TradingOrchestrator) cancelOpenOrders(ctx context.Context) {
+	open := []domain.OrderStatus{domain.StatusPending, domain.StatusExecuting, domain.StatusPartiallyFilled}
+	failed := make(map[string]bool)
+	var cancelled int
+
+	for ctx.Err() == nil {
+		orders, err := to.repo.ListStaleOrders(ctx, open, time.Now(), killSwitchBatchSize+len(failed))
+		if err != nil {
+			to.logger.Error("Failed to list open orders", zap.Error(err))
+			break
+		}
+
+		progress := false
+		for _, order := range orders {
+			if failed[order.ID] {
+				continue
+			}
+			progress = true
+			if _, err := to.CancelOrder(ctx, order.ID); err != nil {
+				failed[order.ID] = true
+				to.logger.Error("Kill switch failed to cancel order",
+					zap.String("order_id", order.ID),
+					zap.Error(err),
+				)
+				continue
+			}
+			cancelled++
+		}
+		if !progress {
+			break
+		}
+	}
+
+	to.logger.Warn("Kill switch cancelled open orders",
+		zap.Int("cancelled", cancelled),
+		zap.Int("failed", len(failed)),
+	)
+}
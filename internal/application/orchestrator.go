@@ -3,7 +3,9 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,114 +13,457 @@ import (
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/messaging"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/persistence"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// OrderJob asks the worker pool to process an order and reports the outcome on Result
+type OrderJob struct {
+	OrderID string
+	Result  chan<- error
+}
+
 // TradingOrchestrator coordinates order processing
 type TradingOrchestrator struct {
-	repo       *persistence.PostgresRepository
-	exchange   exchange.BinanceClient
-	kafkaPool  messaging.KafkaPoolInterface
-	logger     *zap.Logger
-	workerPool int
-	wg         sync.WaitGroup
-	ctx        context.Context
-	cancel     context.CancelFunc
+	repo                 *persistence.PostgresRepository
+	exchange             exchange.ExchangeClient
+	kafkaPool            messaging.KafkaPoolInterface
+	risk                 *RiskEngine
+	symbols              *SymbolCache
+	halt                 *killSwitch
+	logger               *zap.Logger
+	workerPool           int
+	idempotencyRetention time.Duration
+	jobs                 chan<- OrderJob
+	wg                   sync.WaitGroup
+	ctx                  context.Context
+	cancel               context.CancelFunc
 }
 
 // NewTradingOrchestrator creates a new trading orchestrator
 func NewTradingOrchestrator(
 	repo *persistence.PostgresRepository,
-	exchangeClient exchange.BinanceClient,
+	exchangeClient exchange.ExchangeClient,
 	kafkaPool messaging.KafkaPoolInterface,
+	risk *RiskEngine,
 	logger *zap.Logger,
 	workerPool int,
+	idempotencyRetention time.Duration,
 ) *TradingOrchestrator {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &TradingOrchestrator{
-		repo:       repo,
-		exchange:   exchangeClient,
-		kafkaPool:  kafkaPool,
-		logger:     logger,
-		workerPool: workerPool,
-		ctx:        ctx,
-		cancel:     cancel,
+		repo:                 repo,
+		exchange:             exchangeClient,
+		kafkaPool:            kafkaPool,
+		risk:                 risk,
+		symbols:              NewSymbolCache(exchangeClient),
+		halt:                 newKillSwitch(),
+		logger:               logger,
+		workerPool:           workerPool,
+		idempotencyRetention: idempotencyRetention,
+		ctx:                  ctx,
+		cancel:               cancel,
 	}
 }
 
-// SubmitOrder submits a new order for processing
-func (to *TradingOrchestrator) SubmitOrder(ctx context.Context, order *domain.Order) error {
+// Risk returns the pre-trade risk engine
+func (to *TradingOrchestrator) Risk() *RiskEngine {
+	return to.risk
+}
+
+// SubmitOrder submits a new order for processing. The order is first validated
+// against the rules of its type and rounded to the tick and step sizes of its
+// symbol; one that breaks them fails with domain.ErrInvalidOrder and is not stored.
+// Submissions are idempotent on the order ID and, when given, on idempotencyKey:
+// replaying the same payload returns the stored order with created set to false,
+// while a different payload fails with domain.ErrIdempotencyConflict.
+//
+// Orders failing the pre-trade risk checks, or submitted while the kill switch is
+// engaged, are stored as REJECTED with the reason code and an OrderRejected event,
+// and are never sent for execution.
+func (to *TradingOrchestrator) SubmitOrder(ctx context.Context, order *domain.Order, idempotencyKey string) (*domain.Order, bool, error) {
+	if err := order.Validate(); err != nil {
+		return nil, false, err
+	}
+	// Normalize before the replay check, so a replay matches the stored rounded order
+	if err := to.symbols.Normalize(ctx, order); err != nil {
+		return nil, false, err
+	}
+
+	if existing, err := to.findReplayedOrder(ctx, order, idempotencyKey); err != nil || existing != nil {
+		return existing, false, err
+	}
+
+	halt, err := to.KillSwitch(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if halt.Engaged {
+		order.Reject(domain.RejectKillSwitch)
+		to.logger.Warn("Order rejected by kill switch", zap.String("order_id", order.ID))
+	} else if to.risk != nil {
+		reason, err := to.risk.Check(ctx, order)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to run risk checks: %w", err)
+		}
+		if reason != "" {
+			to.rejectByRisk(order, reason)
+		}
+	}
+
 	to.logger.Info("Submitting order",
 		zap.String("order_id", order.ID),
 		zap.String("symbol", order.Symbol),
 		zap.String("side", string(order.Side)),
 	)
 
-	// Create order in database
-	if err := to.repo.CreateOrder(ctx, order); err != nil {
-		return fmt.Errorf("failed to create order: %w", err)
+	if order.CorrelationID == "" {
+		order.CorrelationID = order.ID
 	}
 
-	// Create outbox event
-	event := &domain.OutboxEvent{
-		Aggregate:   "Order",
-		AggregateID: order.ID,
-		EventType:   "OrderSubmitted",
-		Payload:     mustMarshal(order),
-		Processed:   false,
-	}
+	// Create order, outbox event and idempotency key atomically. The rate and
+	// position checks run in the same transaction, so they count every order
+	// committed before this one.
+	err = to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		if order.Status != domain.StatusRejected && to.risk != nil {
+			reason, err := to.risk.CheckExposure(uow, order)
+			if err != nil {
+				return fmt.Errorf("failed to run risk checks: %w", err)
+			}
+			if reason != "" {
+				to.rejectByRisk(order, reason)
+			}
+		}
 
-	if err := to.repo.CreateOutboxEvent(ctx, event); err != nil {
-		return fmt.Errorf("failed to create outbox event: %w", err)
+		if err := uow.CreateOrder(order); err != nil {
+			return err
+		}
+		// The submission is caused by the request that carried it
+		submitted := newOrderEvent(domain.EventOrderSubmitted, order)
+		if order.Status == domain.StatusRejected {
+			submitted = newOrderRejectedEvent(order)
+		}
+		submitted.CausationID = order.CorrelationID
+		if err := uow.CreateOutboxEvent(submitted); err != nil {
+			return err
+		}
+		if idempotencyKey != "" {
+			return uow.SaveIdempotencyKey(domain.NewIdempotencyKey(idempotencyKey, order, to.idempotencyRetention))
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrDuplicateOrder) || errors.Is(err, domain.ErrIdempotencyConflict) {
+		// Lost a race with a concurrent submission of the same ID or key
+		existing, findErr := to.findReplayedOrder(ctx, order, idempotencyKey)
+		if findErr != nil || existing != nil {
+			return existing, false, findErr
+		}
+		return nil, false, domain.ErrIdempotencyConflict
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create order: %w", err)
 	}
 
-	return nil
+	return order, true, nil
 }
 
-// ProcessOrder processes an order from the queue
-func (to *TradingOrchestrator) ProcessOrder(ctx context.Context, orderID string) error {
-	order, err := to.repo.GetOrder(ctx, orderID)
+// rejectByRisk marks an order rejected by the pre-trade risk checks
+func (to *TradingOrchestrator) rejectByRisk(order *domain.Order, reason string) {
+	order.Reject(reason)
+	to.logger.Warn("Order rejected by risk checks",
+		zap.String("order_id", order.ID),
+		zap.String("symbol", order.Symbol),
+		zap.String("reason", reason),
+	)
+}
+
+// findReplayedOrder returns the stored order if order repeats an earlier submission
+// under the same idempotency key or ID, or domain.ErrIdempotencyConflict if the
+// payload differs. It returns nil if the submission is new.
+func (to *TradingOrchestrator) findReplayedOrder(ctx context.Context, order *domain.Order, idempotencyKey string) (*domain.Order, error) {
+	if idempotencyKey != "" {
+		record, err := to.repo.GetIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		if record != nil {
+			if record.Fingerprint != order.Fingerprint() {
+				return nil, domain.ErrIdempotencyConflict
+			}
+			existing, err := to.repo.GetOrder(ctx, record.OrderID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get order: %w", err)
+			}
+			return existing, nil
+		}
+	}
+
+	existing, err := to.repo.GetOrder(ctx, order.ID)
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get order: %w", err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	if !order.CanTransitionTo(domain.StatusExecuting) {
-		return fmt.Errorf("order cannot transition to EXECUTING from %s", order.Status)
+	if existing.Fingerprint() != order.Fingerprint() {
+		return nil, domain.ErrIdempotencyConflict
 	}
+	return existing, nil
+}
+
+// StartIdempotencyKeyPurge periodically deletes idempotency keys past their retention window
+func (to *TradingOrchestrator) StartIdempotencyKeyPurge(interval time.Duration) {
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := to.repo.DeleteExpiredIdempotencyKeys(to.ctx)
+				if err != nil {
+					to.logger.Error("Failed to purge idempotency keys", zap.Error(err))
+					continue
+				}
+				if deleted > 0 {
+					to.logger.Info("Purged expired idempotency keys", zap.Int64("count", deleted))
+				}
+			}
+		}
+	}()
+}
+
+// ProcessOrder processes an order from the queue. Orders the exchange rejects are
+// marked FAILED; when the placement outcome is unknown, such as after a timeout,
+// the order stays EXECUTING so the reconciler can look it up on the exchange.
+// While the kill switch is engaged the order is left PENDING and
+// domain.ErrTradingHalted is returned.
+func (to *TradingOrchestrator) ProcessOrder(ctx context.Context, orderID string) error {
+	var order *domain.Order
+
+	// Claim the order for execution; the row lock keeps concurrent workers from both
+	// claiming it, and the shared lock on the kill switch keeps it from being engaged
+	// until the claim is committed
+	err := to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		halt, err := uow.GetKillSwitchForShare()
+		if err != nil {
+			return fmt.Errorf("failed to get kill switch: %w", err)
+		}
+		if halt.Engaged {
+			return domain.ErrTradingHalted
+		}
 
-	// Update status to EXECUTING
-	order.Status = domain.StatusExecuting
-	if err := to.repo.UpdateOrder(ctx, order); err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+		order, err = uow.GetOrderForUpdate(orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if !order.CanTransitionTo(domain.StatusExecuting) {
+			return fmt.Errorf("order cannot transition to EXECUTING from %s: %w", order.Status, domain.ErrInvalidTransition)
+		}
+
+		order.Status = domain.StatusExecuting
+		order.UpdatedAt = time.Now()
+		return to.saveOrderChange(uow, order, nil, domain.EventOrderExecuting)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Execute trade on exchange
-	if err := to.exchange.ExecuteTrade(ctx, order); err != nil {
-		order.Status = domain.StatusFailed
-		order.UpdatedAt = time.Now()
-		if updateErr := to.repo.UpdateOrder(ctx, order); updateErr != nil {
+	resp, err := to.exchange.ExecuteTrade(ctx, order)
+	if err != nil && !errors.Is(err, exchange.ErrOrderRejected) {
+		// The order may have reached the exchange; it stays EXECUTING until the
+		// execution stream or the reconciler learns its fate
+		to.logger.Warn("Trade placement outcome unknown, leaving order for reconciliation",
+			zap.String("order_id", order.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("trade execution outcome unknown: %w", err)
+	}
+	if err != nil {
+		updateErr := to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+			order, err := uow.GetOrderForUpdate(orderID)
+			if err != nil {
+				return fmt.Errorf("failed to get order: %w", err)
+			}
+			if !order.CanTransitionTo(domain.StatusFailed) {
+				return nil
+			}
+			order.Status = domain.StatusFailed
+			order.UpdatedAt = time.Now()
+			return to.saveOrderChange(uow, order, nil, domain.EventOrderFailed)
+		})
+		if updateErr != nil {
 			return fmt.Errorf("trade failed and update failed: %w (original: %v)", updateErr, err)
 		}
 		return fmt.Errorf("trade execution failed: %w", err)
 	}
 
-	// Update status to COMPLETED
-	order.Status = domain.StatusCompleted
-	order.UpdatedAt = time.Now()
-	if err := to.repo.UpdateOrder(ctx, order); err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	// Record fill state reported by the exchange; resting orders stay EXECUTING
+	return to.applyExecution(ctx, resp)
+}
+
+// HandleExecutionReport applies order state pushed asynchronously by the exchange
+func (to *TradingOrchestrator) HandleExecutionReport(ctx context.Context, resp *exchange.OrderResponse) error {
+	return to.applyExecution(ctx, resp)
+}
+
+// applyExecution records the execution state reported by the exchange on the order
+// it refers to. The order row is locked so the synchronous placement response and
+// asynchronous reports for the same order are applied one at a time.
+func (to *TradingOrchestrator) applyExecution(ctx context.Context, resp *exchange.OrderResponse) error {
+	return to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		order, err := uow.GetOrderForUpdate(resp.ClientOrderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order %s: %w", resp.ClientOrderID, err)
+		}
+
+		// Reports can arrive out of order; never move the executed totals backwards
+		if resp.ExecutedQty.LessThan(order.ExecutedQuantity) {
+			to.logger.Debug("Ignoring stale execution report",
+				zap.String("order_id", order.ID),
+				zap.String("exchange_status", resp.Status),
+			)
+			return nil
+		}
+
+		prevStatus, prevExecuted := order.Status, order.ExecutedQuantity
+		order.ApplyExecution(resp.ExecutedQty, resp.CumulativeQuoteQty)
+		if newStatus := resp.OrderStatus(); newStatus != order.Status && order.CanTransitionTo(newStatus) {
+			order.Status = newStatus
+		}
+
+		eventType := ""
+		if order.Status != prevStatus || !order.ExecutedQuantity.Equal(prevExecuted) {
+			eventType = domain.OrderEventType(order.Status)
+		}
+		if err := to.saveOrderChange(uow, order, resp.DomainFills(order.ID), eventType); err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+
+		to.logger.Info("Order executed",
+			zap.String("order_id", order.ID),
+			zap.String("status", string(order.Status)),
+			zap.Stringer("executed_quantity", order.ExecutedQuantity),
+			zap.Stringer("avg_fill_price", order.AvgFillPrice),
+			zap.Int("fills", len(resp.Fills)),
+		)
+		return nil
+	})
+}
+
+// CancelOrder cancels an order, removing it from the exchange if it is already resting there.
+// The order row is locked while its status is checked and written, and CANCELLED is only
+// recorded after an exchange cancel if no fill, report or reconciliation changed the
+// order while the cancel was in flight; an order that moved on meanwhile fails with
+// domain.ErrInvalidTransition and is left for the execution stream to settle.
+func (to *TradingOrchestrator) CancelOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	var order *domain.Order
+	var prevStatus domain.OrderStatus
+	var prevExecuted decimal.Decimal
+
+	// PENDING orders never reached the exchange and are cancelled under the row lock,
+	// so a worker can no longer claim them once the transaction commits
+	err := to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		var err error
+		order, err = uow.GetOrderForUpdate(orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if !order.CanTransitionTo(domain.StatusCancelled) {
+			return fmt.Errorf("order cannot transition to CANCELLED from %s: %w", order.Status, domain.ErrInvalidTransition)
+		}
+
+		to.logger.Info("Cancelling order",
+			zap.String("order_id", order.ID),
+			zap.String("symbol", order.Symbol),
+			zap.String("status", string(order.Status)),
+		)
+
+		prevStatus, prevExecuted = order.Status, order.ExecutedQuantity
+		if order.Status != domain.StatusPending {
+			return nil
+		}
+		order.Status = domain.StatusCancelled
+		order.UpdatedAt = time.Now()
+		return to.saveOrderChange(uow, order, nil, domain.EventOrderCancelled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.StatusCancelled {
+		return order, nil
 	}
 
-	// Publish completion event
-	if err := to.kafkaPool.PublishOrderEvent(ctx, order); err != nil {
-		to.logger.Error("Failed to publish order completion event", zap.Error(err))
+	// The exchange call is made without holding the row lock, so execution reports
+	// for the order are not blocked behind it
+	if err := to.exchange.CancelOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("exchange cancel failed: %w", err)
 	}
 
-	return nil
+	err = to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		var err error
+		order, err = uow.GetOrderForUpdate(orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order.Status == domain.StatusCancelled {
+			// The execution stream recorded the cancel first
+			return nil
+		}
+		if order.Status != prevStatus || !order.ExecutedQuantity.Equal(prevExecuted) {
+			return fmt.Errorf("order moved to %s while cancelling: %w", order.Status, domain.ErrInvalidTransition)
+		}
+
+		order.Status = domain.StatusCancelled
+		order.UpdatedAt = time.Now()
+		return to.saveOrderChange(uow, order, nil, domain.EventOrderCancelled)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	return order, nil
+}
+
+// saveOrderChange writes an order, its new fills and, unless eventType is empty,
+// an outbox event with the order snapshot through the same unit of work
+func (to *TradingOrchestrator) saveOrderChange(uow *persistence.UnitOfWork, order *domain.Order, fills []*domain.Fill, eventType string) error {
+	if err := uow.UpdateOrder(order); err != nil {
+		return err
+	}
+	if err := uow.CreateFills(fills); err != nil {
+		return err
+	}
+	if eventType == "" {
+		return nil
+	}
+	return appendOrderEvent(uow, newOrderEvent(eventType, order))
+}
+
+// appendOrderEvent writes an order event, recording the previous event of the
+// order as its cause
+func appendOrderEvent(uow *persistence.UnitOfWork, event *domain.OutboxEvent) error {
+	prev, err := uow.LastOutboxEventID(event.Aggregate, event.AggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to get previous order event: %w", err)
+	}
+	if prev != 0 {
+		event.CausationID = strconv.FormatUint(prev, 10)
+	}
+	return uow.CreateOutboxEvent(event)
 }
 
 // StartWorkerPool starts the worker pool for order processing
-func (to *TradingOrchestrator) StartWorkerPool(orderChan chan *domain.Order) {
+func (to *TradingOrchestrator) StartWorkerPool(orderChan chan OrderJob) {
+	to.jobs = orderChan
+
 	for i := 0; i < to.workerPool; i++ {
 		to.wg.Add(1)
 		go func(workerID int) {
@@ -130,55 +475,79 @@ func (to *TradingOrchestrator) StartWorkerPool(orderChan chan *domain.Order) {
 				case <-to.ctx.Done():
 					to.logger.Info("Worker shutting down", zap.Int("worker_id", workerID))
 					return
-				case order := <-orderChan:
-					if err := to.ProcessOrder(to.ctx, order.ID); err != nil {
+				case job := <-orderChan:
+					err := to.processWhenTrading(job.OrderID)
+					if to.ctx.Err() != nil {
+						job.Result <- err
+						return
+					}
+					if err != nil {
 						to.logger.Error("Failed to process order",
-							zap.String("order_id", order.ID),
+							zap.String("order_id", job.OrderID),
 							zap.Error(err),
 						)
 					}
+					job.Result <- err
 				}
 			}
 		}(i)
 	}
 }
 
-// StartOutboxRelay starts the outbox relay process
-func (to *TradingOrchestrator) StartOutboxRelay(interval time.Duration) {
-	to.wg.Add(1)
-	go func() {
-		defer to.wg.Done()
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+// DispatchOrder hands a submitted order to the worker pool and waits for it to be
+// processed. The consumer calls it concurrently, so the workers process several
+// orders at once and each call waits only for its own. Orders that were already
+// claimed by an earlier delivery count as processed, so redelivered messages can
+// be committed.
+func (to *TradingOrchestrator) DispatchOrder(order *domain.Order) error {
+	result := make(chan error, 1)
 
-		for {
-			select {
-			case <-to.ctx.Done():
-				return
-			case <-ticker.C:
-				events, err := to.repo.GetUnprocessedOutboxEvents(to.ctx, 100)
-				if err != nil {
-					to.logger.Error("Failed to get unprocessed events", zap.Error(err))
-					continue
-				}
+	select {
+	case to.jobs <- OrderJob{OrderID: order.ID, Result: result}:
+	case <-to.ctx.Done():
+		return to.ctx.Err()
+	}
 
-				for _, event := range events {
-					if err := to.kafkaPool.PublishGenericEvent(
-						to.ctx,
-						"nexus.events",
-						event.AggregateID,
-						event,
-					); err != nil {
-						to.logger.Error("Failed to publish event", zap.Error(err))
-						continue
-					}
+	select {
+	case err := <-result:
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			to.logger.Info("Order already processed", zap.String("order_id", order.ID))
+			return nil
+		}
+		return err
+	case <-to.ctx.Done():
+		return to.ctx.Err()
+	}
+}
 
-					if err := to.repo.MarkOutboxEventProcessed(to.ctx, event.ID); err != nil {
-						to.logger.Error("Failed to mark event as processed", zap.Error(err))
-					}
-				}
-			}
+// StartExecutionStream consumes execution reports pushed by the exchange,
+// reconnecting with backoff. Venues without a stream are skipped.
+func (to *TradingOrchestrator) StartExecutionStream() {
+	streamer, ok := to.exchange.(exchange.ExecutionStreamer)
+	if !ok {
+		to.logger.Info("Exchange venue has no execution stream")
+		return
+	}
+
+	handler := func(ctx context.Context, resp *exchange.OrderResponse) {
+		if err := to.HandleExecutionReport(ctx, resp); err != nil {
+			to.logger.Error("Failed to handle execution report",
+				zap.String("order_id", resp.ClientOrderID),
+				zap.String("exchange_status", resp.Status),
+				zap.Error(err),
+			)
 		}
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		exchange.RunExecutionStream(to.ctx, streamer, handler, time.Second, func(err error, backoff time.Duration) {
+			to.logger.Warn("Execution stream disconnected, reconnecting",
+				zap.Duration("backoff", backoff),
+				zap.Error(err),
+			)
+		})
 	}()
 }
 
@@ -188,6 +557,29 @@ func (to *TradingOrchestrator) Stop() {
 	to.wg.Wait()
 }
 
+// newOrderEvent creates an outbox event carrying a snapshot of the order
+func newOrderEvent(eventType string, order *domain.Order) *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		Aggregate:     "Order",
+		AggregateID:   order.ID,
+		EventType:     eventType,
+		Payload:       mustMarshal(domain.NewOrderEventV2(order)),
+		SchemaVersion: domain.EventSchemaVersion,
+		Processed:     false,
+		CorrelationID: order.CorrelationID,
+	}
+}
+
+// newOrderRejectedEvent creates the outbox event recording a rejected order
+func newOrderRejectedEvent(order *domain.Order) *domain.OutboxEvent {
+	event := newOrderEvent(domain.EventOrderRejected, order)
+	event.Payload = mustMarshal(domain.OrderRejectedV2{
+		OrderEventV2: domain.NewOrderEventV2(order),
+		RejectReason: order.RejectReason,
+	})
+	return event
+}
+
 // mustMarshal marshals an object to JSON, panicking on error
 func mustMarshal(v interface{}) string {
 	data, err := json.Marshal(v)
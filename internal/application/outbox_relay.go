@@ -0,0 +1,261 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/messaging"
+	"go.uber.org/zap"
+)
+
+// defaultRelayBatchSize is used when outbox.batch_size is not set
+const defaultRelayBatchSize = 100
+
+// defaultJanitorBatchSize is used when outbox.janitor.batch_size is not set
+const defaultJanitorBatchSize = 1000
+
+// StartOutboxRelay starts the outbox relay process. Events are routed by the
+// outbox.routes table; unrouted OrderSubmitted events go to the orders topic, where
+// the order consumers pick them up for execution, and all others to the events
+// topic. Events that fail to publish are retried with backoff and quarantined once
+// retry is exhausted.
+//
+// Events are claimed with a lease, so several instances can relay in parallel
+// without publishing the same event twice. In notify mode the relay wakes on
+// outbox notifications and only polls every poll interval while the listener is
+// disconnected.
+func (to *TradingOrchestrator) StartOutboxRelay(cfg config.OutboxConfig, topics config.KafkaTopicsConfig) {
+	owner := relayOwner()
+
+	wake := make(chan struct{}, 1)
+	var listening atomic.Bool
+	if cfg.Mode == config.OutboxModeNotify {
+		to.startOutboxListener(wake, &listening)
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(cfg.PollInterval())
+		defer ticker.Stop()
+		lastRun := time.Now()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-wake:
+			case <-ticker.C:
+				if listening.Load() && time.Since(lastRun) < cfg.SweepInterval() {
+					continue
+				}
+			}
+
+			lastRun = time.Now()
+			// Only the oldest pending event of each aggregate is claimed per round,
+			// so keep going until a round finds nothing left to publish
+			for to.relayBatch(cfg, topics, owner) > 0 {
+				if to.ctx.Err() != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// startOutboxListener keeps a LISTEN session open, reconnecting with backoff, and
+// signals wake on every outbox notification. listening reports whether the
+// session is currently up.
+func (to *TradingOrchestrator) startOutboxListener(wake chan<- struct{}, listening *atomic.Bool) {
+	notify := func() {
+		listening.Store(true)
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		backoff := time.Second
+
+		for {
+			started := time.Now()
+			err := to.repo.ListenOutboxEvents(to.ctx, notify)
+			listening.Store(false)
+			if to.ctx.Err() != nil {
+				return
+			}
+
+			if time.Since(started) > time.Minute {
+				backoff = time.Second
+			}
+			to.logger.Warn("Outbox listener disconnected, polling until reconnected",
+				zap.Duration("backoff", backoff),
+				zap.Error(err),
+			)
+
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < time.Minute {
+				backoff *= 2
+			}
+		}
+	}()
+}
+
+// relayBatch claims one batch of outbox events, publishes it in a single write and
+// returns how many events were claimed. Events are marked processed once the
+// broker confirmed them, which with an async producer happens after relayBatch
+// returns; until then their lease keeps later events of the same order waiting.
+func (to *TradingOrchestrator) relayBatch(cfg config.OutboxConfig, topics config.KafkaTopicsConfig, owner string) int {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+
+	events, err := to.repo.ClaimOutboxEvents(to.ctx, owner, cfg.Lease(), batchSize)
+	if err != nil {
+		to.logger.Error("Failed to claim outbox events", zap.Error(err))
+		return 0
+	}
+	if len(events) == 0 {
+		return 0
+	}
+
+	var mu sync.Mutex
+	pending := len(events)
+	published := make([]uint64, 0, len(events))
+
+	messages := make([]messaging.Message, len(events))
+	for i, event := range events {
+		event := event
+		messages[i] = messaging.Message{
+			Topic: relayTopic(cfg, topics, event),
+			Key:   event.AggregateID,
+			Value: event,
+			Delivered: func(err error) {
+				if err != nil {
+					to.recordRelayFailure(event, err, cfg.Retry)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					published = append(published, event.ID)
+				}
+				if pending--; pending == 0 && len(published) > 0 {
+					to.markRelayed(published)
+				}
+			},
+		}
+	}
+
+	// Failures are handled per event by the Delivered callbacks
+	_ = to.kafkaPool.PublishBatch(to.ctx, messages)
+	return len(events)
+}
+
+// markRelayed marks published outbox events as processed
+func (to *TradingOrchestrator) markRelayed(ids []uint64) {
+	if err := to.repo.MarkOutboxEventsProcessed(to.ctx, ids); err != nil {
+		to.logger.Error("Failed to mark events as processed", zap.Int("count", len(ids)), zap.Error(err))
+	}
+}
+
+// relayTopic returns the topic an outbox event is published to
+func relayTopic(cfg config.OutboxConfig, topics config.KafkaTopicsConfig, event *domain.OutboxEvent) string {
+	if topic := cfg.TopicFor(event.Aggregate, event.EventType); topic != "" {
+		return topic
+	}
+	if event.EventType == domain.EventOrderSubmitted {
+		return topics.Orders
+	}
+	return topics.Events
+}
+
+// recordRelayFailure schedules the next publish attempt of an event or quarantines it
+func (to *TradingOrchestrator) recordRelayFailure(event *domain.OutboxEvent, cause error, retry config.RetryConfig) {
+	event.RecordFailure(cause, retry.MaxAttempts, retry.Backoff(event.Attempts+1))
+
+	if err := to.repo.RecordOutboxEventFailure(to.ctx, event); err != nil {
+		to.logger.Error("Failed to record outbox event failure", zap.Uint64("event_id", event.ID), zap.Error(err))
+	}
+
+	if event.Quarantined {
+		to.logger.Error("Outbox event quarantined",
+			zap.Uint64("event_id", event.ID),
+			zap.String("event_type", event.EventType),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(cause),
+		)
+		return
+	}
+	to.logger.Warn("Failed to publish event, will retry",
+		zap.Uint64("event_id", event.ID),
+		zap.Int("attempts", event.Attempts),
+		zap.Timep("next_attempt_at", event.NextAttemptAt),
+		zap.Error(cause),
+	)
+}
+
+// StartOutboxJanitor periodically deletes processed events past the retention window,
+// archiving them first if configured. It works in batches so the relay never waits on it.
+func (to *TradingOrchestrator) StartOutboxJanitor(cfg config.OutboxJanitorConfig) {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultJanitorBatchSize
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(cfg.Interval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-ticker.C:
+				before := time.Now().Add(-cfg.Retention())
+				var total int64
+				for to.ctx.Err() == nil {
+					purged, err := to.repo.PurgeProcessedOutboxEvents(to.ctx, before, batchSize, cfg.Archive)
+					if err != nil {
+						to.logger.Error("Failed to purge outbox events", zap.Error(err))
+						break
+					}
+					total += purged
+					if purged < int64(batchSize) {
+						break
+					}
+				}
+				if total > 0 {
+					to.logger.Info("Purged processed outbox events",
+						zap.Int64("count", total),
+						zap.Bool("archived", cfg.Archive),
+					)
+				}
+			}
+		}
+	}()
+}
+
+// relayOwner identifies this process as the holder of outbox leases
+func relayOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/persistence"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// defaultReconcileBatchSize is used when reconciler.batch_size is not set
+const defaultReconcileBatchSize = 50
+
+// inFlightStatuses are the statuses of orders that may have changed on the exchange
+var inFlightStatuses = []domain.OrderStatus{domain.StatusExecuting, domain.StatusPartiallyFilled}
+
+// ReconcileStaleOrders queries the exchange for orders that have been in flight
+// longer than staleAfter and corrects their local status and fills
+func (to *TradingOrchestrator) ReconcileStaleOrders(ctx context.Context, staleAfter time.Duration, limit int) error {
+	orders, err := to.repo.ListStaleOrders(ctx, inFlightStatuses, time.Now().Add(-staleAfter), limit)
+	if err != nil {
+		return fmt.Errorf("failed to list stale orders: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := to.reconcileOrder(ctx, order); err != nil {
+			to.logger.Error("Failed to reconcile order",
+				zap.String("order_id", order.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// reconcileOrder aligns a single order with the exchange's view of it. The exchange
+// is queried without holding the order row; the row is then locked and the result
+// is only applied if the order has not been updated since it was listed, so a fill
+// or cancel recorded meanwhile is never undone.
+func (to *TradingOrchestrator) reconcileOrder(ctx context.Context, listed *domain.Order) error {
+	resp, err := to.exchange.QueryOrder(ctx, listed)
+	missing := errors.Is(err, exchange.ErrOrderNotFound)
+	if err != nil && !missing {
+		return fmt.Errorf("failed to query order: %w", err)
+	}
+
+	var order *domain.Order
+	var prevStatus domain.OrderStatus
+	var prevExecuted decimal.Decimal
+	var changed, stuck bool
+	exchangeStatus := "NOT_FOUND"
+
+	err = to.repo.InTransaction(ctx, func(uow *persistence.UnitOfWork) error {
+		var err error
+		order, err = uow.GetOrderForUpdate(listed.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if !order.UpdatedAt.Equal(listed.UpdatedAt) {
+			// Moved on while the exchange was queried; no longer stale
+			order = nil
+			return nil
+		}
+		prevStatus, prevExecuted = order.Status, order.ExecutedQuantity
+		if !missing {
+			exchangeStatus = resp.Status
+		}
+
+		var fills []*domain.Fill
+		fills, stuck = applyExchangeView(order, resp, missing)
+
+		// The order is saved even when unchanged or stuck so its updated_at moves
+		// and it is not queried again before it is stale once more
+		changed = order.Status != prevStatus || !order.ExecutedQuantity.Equal(prevExecuted)
+		order.UpdatedAt = time.Now()
+		if err := uow.UpdateOrder(order); err != nil {
+			return err
+		}
+		if err := uow.CreateFills(fills); err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		return appendOrderEvent(uow, &domain.OutboxEvent{
+			Aggregate:     "Order",
+			AggregateID:   order.ID,
+			EventType:     domain.EventOrderReconciled,
+			CorrelationID: order.CorrelationID,
+			Payload: mustMarshal(domain.OrderReconciledV2{
+				OrderEventV2:             domain.NewOrderEventV2(order),
+				PreviousStatus:           prevStatus,
+				ExchangeStatus:           exchangeStatus,
+				PreviousExecutedQuantity: prevExecuted,
+			}),
+			SchemaVersion: domain.EventSchemaVersion,
+			Processed:     false,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+	if order == nil {
+		to.logger.Debug("Order changed during reconciliation, skipping", zap.String("order_id", listed.ID))
+		return nil
+	}
+	if stuck {
+		to.logger.Warn("Order missing on exchange but cannot fail, needs manual attention",
+			zap.String("order_id", order.ID),
+			zap.String("status", string(order.Status)),
+			zap.String("executed_quantity", order.ExecutedQuantity.String()),
+		)
+		return nil
+	}
+	if !changed {
+		return nil
+	}
+
+	to.logger.Info("Reconciled order",
+		zap.String("order_id", order.ID),
+		zap.String("previous_status", string(prevStatus)),
+		zap.String("status", string(order.Status)),
+		zap.String("exchange_status", exchangeStatus),
+	)
+
+	return nil
+}
+
+// applyExchangeView moves an order to the exchange's view of it and returns the
+// fills to record. missing means the exchange does not know the order, so the
+// placement never reached it and the order fails. An order that cannot fail from
+// its status, such as one with fills, is left as it is and reported as stuck.
+func applyExchangeView(order *domain.Order, resp *exchange.OrderResponse, missing bool) ([]*domain.Fill, bool) {
+	if missing {
+		if !order.CanTransitionTo(domain.StatusFailed) {
+			return nil, true
+		}
+		order.Status = domain.StatusFailed
+		return nil, false
+	}
+
+	order.ApplyExecution(resp.ExecutedQty, resp.CumulativeQuoteQty)
+	if newStatus := resp.OrderStatus(); newStatus != order.Status && order.CanTransitionTo(newStatus) {
+		order.Status = newStatus
+	}
+	return resp.DomainFills(order.ID), false
+}
+
+// StartReconciler starts the periodic order status reconciliation
+func (to *TradingOrchestrator) StartReconciler(interval, staleAfter time.Duration, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = defaultReconcileBatchSize
+	}
+
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := to.ReconcileStaleOrders(to.ctx, staleAfter, batchSize); err != nil {
+					to.logger.Error("Order reconciliation failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
+	"github.com/shopspring/decimal"
+)
+
+func reconcileTestOrder(status domain.OrderStatus, executed string) *domain.Order {
+	order := domain.NewOrder("order-1", "BTCUSDT", domain.SideBuy, domain.TypeLimit,
+		decimal.RequireFromString("1"), decimal.RequireFromString("64000"))
+	order.Status = status
+	order.ExecutedQuantity = decimal.RequireFromString(executed)
+	return order
+}
+
+func TestApplyExchangeView(t *testing.T) {
+	filled := &exchange.OrderResponse{
+		ClientOrderID:      "order-1",
+		Status:             "FILLED",
+		ExecutedQty:        decimal.RequireFromString("1"),
+		CumulativeQuoteQty: decimal.RequireFromString("64000"),
+		Fills: []exchange.FillResponse{
+			{Price: decimal.RequireFromString("64000"), Qty: decimal.RequireFromString("1"), TradeID: 9},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		order        *domain.Order
+		resp         *exchange.OrderResponse
+		missing      bool
+		wantStatus   domain.OrderStatus
+		wantExecuted string
+		wantFills    int
+		wantStuck    bool
+	}{
+		{
+			name:         "executing order missing on the exchange fails",
+			order:        reconcileTestOrder(domain.StatusExecuting, "0"),
+			missing:      true,
+			wantStatus:   domain.StatusFailed,
+			wantExecuted: "0",
+		},
+		{
+			name:         "partially filled order missing on the exchange is stuck",
+			order:        reconcileTestOrder(domain.StatusPartiallyFilled, "0.4"),
+			missing:      true,
+			wantStatus:   domain.StatusPartiallyFilled,
+			wantExecuted: "0.4",
+			wantStuck:    true,
+		},
+		{
+			name:         "partially filled order filled on the exchange completes",
+			order:        reconcileTestOrder(domain.StatusPartiallyFilled, "0.4"),
+			resp:         filled,
+			wantStatus:   domain.StatusCompleted,
+			wantExecuted: "1",
+			wantFills:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fills, stuck := applyExchangeView(tt.order, tt.resp, tt.missing)
+			if stuck != tt.wantStuck {
+				t.Errorf("expected stuck %v, got %v", tt.wantStuck, stuck)
+			}
+			if tt.order.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, tt.order.Status)
+			}
+			if want := decimal.RequireFromString(tt.wantExecuted); !tt.order.ExecutedQuantity.Equal(want) {
+				t.Errorf("expected executed quantity %s, got %s", want, tt.order.ExecutedQuantity)
+			}
+			if len(fills) != tt.wantFills {
+				t.Errorf("expected %d fills, got %d", tt.wantFills, len(fills))
+			}
+		})
+	}
+}
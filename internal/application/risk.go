@@ -0,0 +1,236 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/persistence"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidRiskLimits is returned when new risk limits are rejected
+var ErrInvalidRiskLimits = errors.New("invalid risk limits")
+
+// RiskEngine runs pre-trade risk checks. Its limits start from the configuration
+// and can be updated at runtime; runtime changes are not persisted.
+type RiskEngine struct {
+	exchange exchange.ExchangeClient
+	mu       sync.RWMutex
+	limits   config.RiskConfig
+}
+
+// NewRiskEngine creates a risk engine with the configured limits
+func NewRiskEngine(cfg config.RiskConfig, exchangeClient exchange.ExchangeClient) *RiskEngine {
+	return &RiskEngine{
+		exchange: exchangeClient,
+		limits:   cloneRiskConfig(cfg),
+	}
+}
+
+// Limits returns a copy of the current limits
+func (r *RiskEngine) Limits() config.RiskConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return cloneRiskConfig(r.limits)
+}
+
+// UpdateLimits merges a JSON document with fields of config.RiskConfig into the
+// current limits and returns the limits in effect. Fields left out keep their
+// current values. A symbol entry is merged into the symbol's current limits, or
+// into the default limits if it has none; a null entry removes the symbol's limits.
+func (r *RiskEngine) UpdateLimits(update []byte) (config.RiskConfig, error) {
+	var symbolUpdates struct {
+		Symbols map[string]json.RawMessage `json:"symbols"`
+	}
+	if err := json.Unmarshal(update, &symbolUpdates); err != nil {
+		return config.RiskConfig{}, fmt.Errorf("%w: %v", ErrInvalidRiskLimits, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limits := cloneRiskConfig(r.limits)
+	symbols := limits.Symbols
+	// Symbols are merged entry by entry below, not replaced wholesale
+	limits.Symbols = nil
+	if err := json.Unmarshal(update, &limits); err != nil {
+		return config.RiskConfig{}, fmt.Errorf("%w: %v", ErrInvalidRiskLimits, err)
+	}
+	limits.Symbols = symbols
+
+	for symbol, raw := range symbolUpdates.Symbols {
+		if string(raw) == "null" {
+			delete(symbols, symbol)
+			continue
+		}
+		entry, ok := symbols[symbol]
+		if !ok {
+			entry = limits.Default
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return config.RiskConfig{}, fmt.Errorf("%w: %s: %v", ErrInvalidRiskLimits, symbol, err)
+		}
+		symbols[symbol] = entry
+	}
+
+	if err := validateRiskLimits(limits); err != nil {
+		return config.RiskConfig{}, err
+	}
+	r.limits = limits
+	return cloneRiskConfig(limits), nil
+}
+
+// Check runs the pre-trade checks that depend only on the order and the market
+// price, and returns the reason code the order is rejected with, or "" if it
+// passes. Errors are reserved for failures to evaluate the checks. When the last
+// trade price is needed but unavailable the order is rejected rather than let
+// through unchecked. The checks against stored orders are run by CheckExposure.
+func (r *RiskEngine) Check(ctx context.Context, order *domain.Order) (string, error) {
+	cfg := r.Limits()
+	if !cfg.Enabled {
+		return "", nil
+	}
+	account, limits := cfg.Account, cfg.LimitsFor(order.Symbol)
+
+	// Orders without a limit price are priced at their stop price, or else the last
+	// trade price, which also anchors the price band
+	needsNotional := account.MaxOrderNotional.IsPositive() || limits.MaxOrderNotional.IsPositive()
+	needsBand := limits.PriceBandBps.IsPositive() && order.Type.HasLimitPrice()
+	price := order.Price
+	if !order.Type.HasLimitPrice() {
+		price = order.StopPrice
+	}
+	if (needsNotional && !price.IsPositive()) || needsBand {
+		lastPrice, err := r.exchange.GetLastPrice(ctx, order.Symbol)
+		if err != nil || !lastPrice.IsPositive() {
+			return domain.RejectPriceUnavailable, nil
+		}
+		if needsBand && exceeds(order.Price.Sub(lastPrice).Abs().Div(lastPrice).Shift(4), limits.PriceBandBps) {
+			return domain.RejectPriceBand, nil
+		}
+		if !price.IsPositive() {
+			price = lastPrice
+		}
+	}
+
+	notional := order.Quantity.Mul(price)
+	if exceeds(notional, account.MaxOrderNotional) || exceeds(notional, limits.MaxOrderNotional) {
+		return domain.RejectMaxNotional, nil
+	}
+	return "", nil
+}
+
+// CheckExposure runs the order rate and position checks against the stored
+// orders, inside the transaction that stores the order. Advisory locks on the
+// account and the symbol make concurrent submissions take these checks one at a
+// time, each seeing the orders committed before it, so they cannot together
+// exceed a limit.
+func (r *RiskEngine) CheckExposure(uow *persistence.UnitOfWork, order *domain.Order) (string, error) {
+	cfg := r.Limits()
+	if !cfg.Enabled {
+		return "", nil
+	}
+	account, limits := cfg.Account, cfg.LimitsFor(order.Symbol)
+
+	// The account lock is always taken before a symbol lock, so waits cannot deadlock
+	if account.MaxOrdersPerMinute > 0 {
+		if err := uow.LockRiskScope("account"); err != nil {
+			return "", fmt.Errorf("failed to lock account risk checks: %w", err)
+		}
+	}
+	if limits.MaxOrdersPerMinute > 0 || limits.MaxPosition.IsPositive() {
+		if err := uow.LockRiskScope("symbol:" + order.Symbol); err != nil {
+			return "", fmt.Errorf("failed to lock %s risk checks: %w", order.Symbol, err)
+		}
+	}
+
+	if reason, err := r.checkOrderRate(uow, order, account, limits); reason != "" || err != nil {
+		return reason, err
+	}
+
+	if limits.MaxPosition.IsPositive() {
+		exposure, err := uow.GetSymbolExposure(order.Symbol)
+		if err != nil {
+			return "", fmt.Errorf("failed to get exposure: %w", err)
+		}
+		if exceeds(exposure.Projected(order.Side, order.Quantity).Abs(), limits.MaxPosition) {
+			return domain.RejectMaxPosition, nil
+		}
+	}
+
+	return "", nil
+}
+
+// checkOrderRate enforces the orders-per-minute limits of the account and the symbol
+func (r *RiskEngine) checkOrderRate(uow *persistence.UnitOfWork, order *domain.Order, account config.AccountRiskLimits, limits config.RiskLimits) (string, error) {
+	since := time.Now().Add(-time.Minute)
+
+	if account.MaxOrdersPerMinute > 0 {
+		count, err := uow.CountOrdersSince("", since)
+		if err != nil {
+			return "", fmt.Errorf("failed to count orders: %w", err)
+		}
+		if count >= int64(account.MaxOrdersPerMinute) {
+			return domain.RejectOrderRate, nil
+		}
+	}
+
+	if limits.MaxOrdersPerMinute > 0 {
+		count, err := uow.CountOrdersSince(order.Symbol, since)
+		if err != nil {
+			return "", fmt.Errorf("failed to count orders: %w", err)
+		}
+		if count >= int64(limits.MaxOrdersPerMinute) {
+			return domain.RejectOrderRate, nil
+		}
+	}
+
+	return "", nil
+}
+
+// exceeds reports whether value is above limit, where a zero limit is disabled
+func exceeds(value, limit decimal.Decimal) bool {
+	return limit.IsPositive() && value.GreaterThan(limit)
+}
+
+// validateRiskLimits rejects negative limits
+func validateRiskLimits(cfg config.RiskConfig) error {
+	if cfg.Account.MaxOrderNotional.IsNegative() || cfg.Account.MaxOrdersPerMinute < 0 {
+		return fmt.Errorf("%w: account limits must not be negative", ErrInvalidRiskLimits)
+	}
+	if err := validateSymbolLimits("default", cfg.Default); err != nil {
+		return err
+	}
+	for symbol, limits := range cfg.Symbols {
+		if err := validateSymbolLimits(symbol, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSymbolLimits rejects negative limits of a symbol
+func validateSymbolLimits(symbol string, limits config.RiskLimits) error {
+	if limits.MaxOrderNotional.IsNegative() || limits.MaxPosition.IsNegative() ||
+		limits.MaxOrdersPerMinute < 0 || limits.PriceBandBps.IsNegative() {
+		return fmt.Errorf("%w: %s limits must not be negative", ErrInvalidRiskLimits, symbol)
+	}
+	return nil
+}
+
+// cloneRiskConfig copies the limits so callers cannot change them through the map
+func cloneRiskConfig(cfg config.RiskConfig) config.RiskConfig {
+	symbols := make(map[string]config.RiskLimits, len(cfg.Symbols))
+	for symbol, limits := range cfg.Symbols {
+		symbols[symbol] = limits
+	}
+	cfg.Symbols = symbols
+	return cfg
+}
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestRiskEngineUpdateLimitsMergesPartialUpdates(t *testing.T) {
+	engine := NewRiskEngine(config.RiskConfig{
+		Enabled: true,
+		Account: config.AccountRiskLimits{
+			MaxOrderNotional:   decimal.RequireFromString("100000"),
+			MaxOrdersPerMinute: 120,
+		},
+		Default: config.RiskLimits{
+			MaxOrdersPerMinute: 30,
+			PriceBandBps:       decimal.RequireFromString("500"),
+		},
+		Symbols: map[string]config.RiskLimits{
+			"BTCUSDT": {
+				MaxOrderNotional:   decimal.RequireFromString("50000"),
+				MaxPosition:        decimal.RequireFromString("2"),
+				MaxOrdersPerMinute: 30,
+				PriceBandBps:       decimal.RequireFromString("500"),
+			},
+			"DOGEUSDT": {MaxOrdersPerMinute: 5},
+		},
+	}, nil)
+
+	limits, err := engine.UpdateLimits([]byte(`{
+		"account": {"max_order_notional": "250000.5"},
+		"symbols": {
+			"BTCUSDT": {"max_position": "0.00000001"},
+			"ETHUSDT": {"max_order_notional": 20000},
+			"DOGEUSDT": null
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to update limits: %v", err)
+	}
+
+	if !limits.Enabled || limits.Account.MaxOrdersPerMinute != 120 {
+		t.Errorf("fields left out of the update changed: %+v", limits)
+	}
+	if want := decimal.RequireFromString("250000.5"); !limits.Account.MaxOrderNotional.Equal(want) {
+		t.Errorf("expected account notional %s, got %s", want, limits.Account.MaxOrderNotional)
+	}
+
+	btc := limits.Symbols["BTCUSDT"]
+	if want := decimal.RequireFromString("0.00000001"); !btc.MaxPosition.Equal(want) {
+		t.Errorf("expected BTCUSDT position %s, got %s", want, btc.MaxPosition)
+	}
+	if !btc.MaxOrderNotional.Equal(decimal.RequireFromString("50000")) || btc.MaxOrdersPerMinute != 30 {
+		t.Errorf("BTCUSDT limits left out of the update changed: %+v", btc)
+	}
+
+	// New symbols start from the default limits
+	eth, ok := limits.Symbols["ETHUSDT"]
+	if !ok || !eth.MaxOrderNotional.Equal(decimal.RequireFromString("20000")) ||
+		eth.MaxOrdersPerMinute != 30 || !eth.PriceBandBps.Equal(decimal.RequireFromString("500")) {
+		t.Errorf("expected ETHUSDT to extend the default limits, got %+v", eth)
+	}
+	if _, ok := limits.Symbols["DOGEUSDT"]; ok {
+		t.Error("expected DOGEUSDT limits to be removed")
+	}
+}
+
+func TestRiskEngineUpdateLimitsRejectsInvalidUpdates(t *testing.T) {
+	engine := NewRiskEngine(config.RiskConfig{
+		Default: config.RiskLimits{MaxPosition: decimal.RequireFromString("3")},
+	}, nil)
+
+	for _, update := range []string{
+		`{"default": {"max_position": "-1"}}`,
+		`{"symbols": {"BTCUSDT": {"max_order_notional": "abc"}}}`,
+		`not json`,
+	} {
+		if _, err := engine.UpdateLimits([]byte(update)); !errors.Is(err, ErrInvalidRiskLimits) {
+			t.Errorf("update %s: expected ErrInvalidRiskLimits, got %v", update, err)
+		}
+	}
+
+	// A rejected update leaves the limits untouched
+	if got := engine.Limits().Default.MaxPosition; !got.Equal(decimal.RequireFromString("3")) {
+		t.Errorf("expected default position 3 after rejected updates, got %s", got)
+	}
+}
@@ -0,0 +1,232 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// SymbolCache caches the exchange metadata and filters of the symbols traded so far.
+// Symbols are loaded on first use and reloaded by StartSymbolRefresh.
+type SymbolCache struct {
+	exchange exchange.ExchangeClient
+	mu       sync.RWMutex
+	symbols  map[string]*exchange.SymbolInfo
+}
+
+// NewSymbolCache creates an empty symbol cache
+func NewSymbolCache(exchangeClient exchange.ExchangeClient) *SymbolCache {
+	return &SymbolCache{
+		exchange: exchangeClient,
+		symbols:  make(map[string]*exchange.SymbolInfo),
+	}
+}
+
+// Get returns the metadata of a symbol, loading it from the exchange on a cache miss
+func (c *SymbolCache) Get(ctx context.Context, symbol string) (*exchange.SymbolInfo, error) {
+	c.mu.RLock()
+	info, ok := c.symbols[symbol]
+	c.mu.RUnlock()
+	if ok {
+		return info, nil
+	}
+
+	info, err := c.exchange.GetSymbolInfo(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.symbols[symbol] = info
+	c.mu.Unlock()
+	return info, nil
+}
+
+// Refresh reloads every cached symbol. Symbols the exchange no longer lists are
+// dropped; symbols that fail to load for other reasons keep their cached metadata.
+func (c *SymbolCache) Refresh(ctx context.Context) error {
+	c.mu.RLock()
+	symbols := make([]string, 0, len(c.symbols))
+	for symbol := range c.symbols {
+		symbols = append(symbols, symbol)
+	}
+	c.mu.RUnlock()
+
+	var errs []error
+	for _, symbol := range symbols {
+		info, err := c.exchange.GetSymbolInfo(ctx, symbol)
+		c.mu.Lock()
+		switch {
+		case err == nil:
+			c.symbols[symbol] = info
+		case errors.Is(err, exchange.ErrSymbolNotFound):
+			delete(c.symbols, symbol)
+		default:
+			errs = append(errs, fmt.Errorf("failed to refresh %s: %w", symbol, err))
+		}
+		c.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Normalize rounds the limit and stop prices of an order to the tick size and its
+// quantity down to the step size of its symbol, then checks it against the symbol's
+// filters. Orders that break a filter fail with domain.ErrInvalidOrder. Orders
+// without a limit price are checked against the notional filters at their stop
+// price, or else the last trade price; when that price is unavailable the check
+// is left to the exchange.
+func (c *SymbolCache) Normalize(ctx context.Context, order *domain.Order) error {
+	info, err := c.Get(ctx, order.Symbol)
+	if errors.Is(err, exchange.ErrSymbolNotFound) {
+		return fmt.Errorf("%w: unknown symbol %s", domain.ErrInvalidOrder, order.Symbol)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get symbol info: %w", err)
+	}
+
+	if info.Status != "" && info.Status != "TRADING" {
+		return fmt.Errorf("%w: %s is not trading (status %s)", domain.ErrInvalidOrder, order.Symbol, info.Status)
+	}
+	if !info.AllowsOrderType(order.Type) {
+		return fmt.Errorf("%w: %s does not accept %s orders", domain.ErrInvalidOrder, order.Symbol, order.Type)
+	}
+	if order.TrailingDelta > 0 && !info.AllowTrailingStop {
+		return fmt.Errorf("%w: %s does not accept trailing stops", domain.ErrInvalidOrder, order.Symbol)
+	}
+
+	if f, ok := info.Filter(exchange.FilterPrice); ok {
+		if order.Type.HasLimitPrice() {
+			if order.Price, err = normalizePrice("price", order.Price, f, order.Symbol); err != nil {
+				return err
+			}
+		}
+		if order.StopPrice.IsPositive() {
+			if order.StopPrice, err = normalizePrice("stop price", order.StopPrice, f, order.Symbol); err != nil {
+				return err
+			}
+		}
+	}
+	if err := normalizeQuantity(order, info); err != nil {
+		return err
+	}
+
+	price := order.Price
+	if !order.Type.HasLimitPrice() {
+		price = order.StopPrice
+	}
+	if !price.IsPositive() {
+		lastPrice, err := c.exchange.GetLastPrice(ctx, order.Symbol)
+		if err != nil || !lastPrice.IsPositive() {
+			return nil
+		}
+		price = lastPrice
+	}
+	return checkNotional(order, info, order.Quantity.Mul(price))
+}
+
+// normalizePrice rounds a limit or stop price to the tick size of the price
+// filter and checks its bounds; name labels the price in errors
+func normalizePrice(name string, price decimal.Decimal, f exchange.SymbolFilter, symbol string) (decimal.Decimal, error) {
+	if f.TickSize.IsPositive() {
+		price = price.DivRound(f.TickSize, 0).Mul(f.TickSize)
+	}
+	if !price.IsPositive() {
+		return price, fmt.Errorf("%w: %s must be positive", domain.ErrInvalidOrder, name)
+	}
+	if f.MinPrice.IsPositive() && price.LessThan(f.MinPrice) {
+		return price, fmt.Errorf("%w: %s %s is below the minimum %s of %s",
+			domain.ErrInvalidOrder, name, price, f.MinPrice, symbol)
+	}
+	if f.MaxPrice.IsPositive() && price.GreaterThan(f.MaxPrice) {
+		return price, fmt.Errorf("%w: %s %s is above the maximum %s of %s",
+			domain.ErrInvalidOrder, name, price, f.MaxPrice, symbol)
+	}
+	return price, nil
+}
+
+// normalizeQuantity rounds the quantity down to the step size and checks its
+// bounds. MARKET orders use the market lot size where the symbol defines one.
+func normalizeQuantity(order *domain.Order, info *exchange.SymbolInfo) error {
+	f, ok := info.Filter(exchange.FilterLotSize)
+	if order.Type == domain.TypeMarket {
+		if market, found := info.Filter(exchange.FilterMarketLotSize); found && market.StepSize.IsPositive() {
+			f, ok = market, true
+		}
+	}
+	if !ok {
+		return nil
+	}
+	if f.StepSize.IsPositive() {
+		order.Quantity = order.Quantity.Div(f.StepSize).Floor().Mul(f.StepSize)
+	}
+	if !order.Quantity.IsPositive() {
+		return fmt.Errorf("%w: quantity is below the step size %s of %s",
+			domain.ErrInvalidOrder, f.StepSize, order.Symbol)
+	}
+	if f.MinQty.IsPositive() && order.Quantity.LessThan(f.MinQty) {
+		return fmt.Errorf("%w: quantity %s is below the minimum %s of %s",
+			domain.ErrInvalidOrder, order.Quantity, f.MinQty, order.Symbol)
+	}
+	if f.MaxQty.IsPositive() && order.Quantity.GreaterThan(f.MaxQty) {
+		return fmt.Errorf("%w: quantity %s is above the maximum %s of %s",
+			domain.ErrInvalidOrder, order.Quantity, f.MaxQty, order.Symbol)
+	}
+	return nil
+}
+
+// checkNotional checks the order value against the MIN_NOTIONAL and NOTIONAL
+// filters, honouring whether they apply to orders without a limit price
+func checkNotional(order *domain.Order, info *exchange.SymbolInfo, notional decimal.Decimal) error {
+	market := !order.Type.HasLimitPrice()
+	minNotional, maxNotional := decimal.Zero, decimal.Zero
+
+	if f, ok := info.Filter(exchange.FilterMinNotional); ok && (!market || f.ApplyToMarket) {
+		minNotional = f.MinNotional
+	}
+	if f, ok := info.Filter(exchange.FilterNotional); ok {
+		if !market || f.ApplyMinToMarket {
+			minNotional = decimal.Max(minNotional, f.MinNotional)
+		}
+		if !market || f.ApplyMaxToMarket {
+			maxNotional = f.MaxNotional
+		}
+	}
+
+	if minNotional.IsPositive() && notional.LessThan(minNotional) {
+		return fmt.Errorf("%w: notional %s is below the minimum %s of %s",
+			domain.ErrInvalidOrder, notional, minNotional, order.Symbol)
+	}
+	if maxNotional.IsPositive() && notional.GreaterThan(maxNotional) {
+		return fmt.Errorf("%w: notional %s is above the maximum %s of %s",
+			domain.ErrInvalidOrder, notional, maxNotional, order.Symbol)
+	}
+	return nil
+}
+
+// StartSymbolRefresh periodically reloads the cached symbol metadata, so filter
+// changes on the exchange are picked up without a restart
+func (to *TradingOrchestrator) StartSymbolRefresh(interval time.Duration) {
+	to.wg.Add(1)
+	go func() {
+		defer to.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-to.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := to.symbols.Refresh(to.ctx); err != nil {
+					to.logger.Warn("Failed to refresh symbol info", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,259 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/exchange"
+	"github.com/shopspring/decimal"
+)
+
+// stubSymbolExchange serves fixed symbol metadata and last price; the other
+// ExchangeClient methods are not used by the symbol cache
+type stubSymbolExchange struct {
+	exchange.ExchangeClient
+	info         *exchange.SymbolInfo
+	lastPrice    decimal.Decimal
+	lastPriceErr error
+}
+
+func (s *stubSymbolExchange) GetSymbolInfo(ctx context.Context, symbol string) (*exchange.SymbolInfo, error) {
+	return s.info, nil
+}
+
+func (s *stubSymbolExchange) GetLastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	return s.lastPrice, s.lastPriceErr
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func testSymbolInfo(filters ...exchange.SymbolFilter) *exchange.SymbolInfo {
+	return &exchange.SymbolInfo{
+		Symbol:            "BTCUSDT",
+		Status:            "TRADING",
+		AllowTrailingStop: true,
+		Filters:           filters,
+	}
+}
+
+func TestSymbolCacheNormalizeRoundsToFilters(t *testing.T) {
+	info := testSymbolInfo(
+		exchange.SymbolFilter{FilterType: exchange.FilterPrice, MinPrice: dec("0.01"), TickSize: dec("0.01")},
+		exchange.SymbolFilter{FilterType: exchange.FilterLotSize, MinQty: dec("0.001"), StepSize: dec("0.001")},
+		exchange.SymbolFilter{FilterType: exchange.FilterMarketLotSize, MinQty: dec("0.1"), StepSize: dec("0.1")},
+	)
+
+	tests := []struct {
+		name          string
+		orderType     domain.OrderType
+		price         string
+		stopPrice     string
+		quantity      string
+		wantPrice     string
+		wantStopPrice string
+		wantQuantity  string
+	}{
+		{
+			name:      "limit price rounds to nearest tick",
+			orderType: domain.TypeLimit, price: "100.006", stopPrice: "0", quantity: "1",
+			wantPrice: "100.01", wantStopPrice: "0", wantQuantity: "1",
+		},
+		{
+			name:      "limit price rounds down to nearest tick",
+			orderType: domain.TypeLimit, price: "100.004", stopPrice: "0", quantity: "1",
+			wantPrice: "100", wantStopPrice: "0", wantQuantity: "1",
+		},
+		{
+			name:      "stop limit rounds both prices",
+			orderType: domain.TypeStopLossLimit, price: "99.994", stopPrice: "100.005", quantity: "1",
+			wantPrice: "99.99", wantStopPrice: "100.01", wantQuantity: "1",
+		},
+		{
+			name:      "stop market rounds stop price only",
+			orderType: domain.TypeStopLoss, price: "0", stopPrice: "100.004", quantity: "1",
+			wantPrice: "0", wantStopPrice: "100", wantQuantity: "1",
+		},
+		{
+			name:      "limit quantity floors to lot step",
+			orderType: domain.TypeLimit, price: "100", stopPrice: "0", quantity: "1.23456",
+			wantPrice: "100", wantStopPrice: "0", wantQuantity: "1.234",
+		},
+		{
+			name:      "market quantity floors to market lot step",
+			orderType: domain.TypeMarket, price: "0", stopPrice: "0", quantity: "1.23456",
+			wantPrice: "0", wantStopPrice: "0", wantQuantity: "1.2",
+		},
+		{
+			name:      "stop market quantity uses lot step",
+			orderType: domain.TypeStopLoss, price: "0", stopPrice: "100", quantity: "1.23456",
+			wantPrice: "0", wantStopPrice: "100", wantQuantity: "1.234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSymbolCache(&stubSymbolExchange{info: info, lastPrice: dec("100")})
+			order := &domain.Order{
+				Symbol:    "BTCUSDT",
+				Side:      domain.SideBuy,
+				Type:      tt.orderType,
+				Price:     dec(tt.price),
+				StopPrice: dec(tt.stopPrice),
+				Quantity:  dec(tt.quantity),
+			}
+
+			if err := cache.Normalize(context.Background(), order); err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if !order.Price.Equal(dec(tt.wantPrice)) {
+				t.Errorf("price = %s, want %s", order.Price, tt.wantPrice)
+			}
+			if !order.StopPrice.Equal(dec(tt.wantStopPrice)) {
+				t.Errorf("stop price = %s, want %s", order.StopPrice, tt.wantStopPrice)
+			}
+			if !order.Quantity.Equal(dec(tt.wantQuantity)) {
+				t.Errorf("quantity = %s, want %s", order.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestSymbolCacheNormalizeRejectsQuantityBelowStep(t *testing.T) {
+	info := testSymbolInfo(
+		exchange.SymbolFilter{FilterType: exchange.FilterLotSize, StepSize: dec("0.001")},
+		exchange.SymbolFilter{FilterType: exchange.FilterMarketLotSize, StepSize: dec("0.1")},
+	)
+	cache := NewSymbolCache(&stubSymbolExchange{info: info, lastPrice: dec("100")})
+
+	// 0.05 is a valid lot but below the market lot step
+	order := &domain.Order{Symbol: "BTCUSDT", Side: domain.SideBuy, Type: domain.TypeMarket, Quantity: dec("0.05")}
+	if err := cache.Normalize(context.Background(), order); !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Fatalf("Normalize() error = %v, want ErrInvalidOrder", err)
+	}
+}
+
+func TestSymbolCacheNormalizeChecksNotional(t *testing.T) {
+	minNotional := func(applyToMarket bool) exchange.SymbolFilter {
+		return exchange.SymbolFilter{FilterType: exchange.FilterMinNotional, MinNotional: dec("10"), ApplyToMarket: applyToMarket}
+	}
+	notional := func(applyMin, applyMax bool) exchange.SymbolFilter {
+		return exchange.SymbolFilter{
+			FilterType:       exchange.FilterNotional,
+			MinNotional:      dec("10"),
+			MaxNotional:      dec("1000"),
+			ApplyMinToMarket: applyMin,
+			ApplyMaxToMarket: applyMax,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		filter       exchange.SymbolFilter
+		orderType    domain.OrderType
+		price        string
+		stopPrice    string
+		quantity     string
+		lastPrice    string
+		lastPriceErr error
+		wantErr      bool
+	}{
+		{
+			name:   "min notional rejects small limit order",
+			filter: minNotional(false), orderType: domain.TypeLimit, price: "100", quantity: "0.05", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "min notional accepts limit order at minimum",
+			filter: minNotional(false), orderType: domain.TypeLimit, price: "100", quantity: "0.1", lastPrice: "100",
+		},
+		{
+			name:   "min notional skips market order without applyToMarket",
+			filter: minNotional(false), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "100",
+		},
+		{
+			name:   "min notional rejects market order with applyToMarket",
+			filter: minNotional(true), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "min notional prices stop market order at stop price",
+			filter: minNotional(true), orderType: domain.TypeStopLoss, stopPrice: "100", quantity: "0.05", lastPrice: "1000",
+			wantErr: true,
+		},
+		{
+			name:   "notional rejects small limit order",
+			filter: notional(false, false), orderType: domain.TypeLimit, price: "100", quantity: "0.05", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "notional rejects large limit order",
+			filter: notional(false, false), orderType: domain.TypeLimit, price: "100", quantity: "20", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "notional skips small market order without applyMinToMarket",
+			filter: notional(false, false), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "100",
+		},
+		{
+			name:   "notional rejects small market order with applyMinToMarket",
+			filter: notional(true, false), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "notional skips large market order without applyMaxToMarket",
+			filter: notional(true, false), orderType: domain.TypeMarket, quantity: "20", lastPrice: "100",
+		},
+		{
+			name:   "notional rejects large market order with applyMaxToMarket",
+			filter: notional(false, true), orderType: domain.TypeMarket, quantity: "20", lastPrice: "100",
+			wantErr: true,
+		},
+		{
+			name:   "market order skips check when last price fails",
+			filter: minNotional(true), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "0",
+			lastPriceErr: errors.New("ticker unavailable"),
+		},
+		{
+			name:   "market order skips check when last price is zero",
+			filter: notional(true, true), orderType: domain.TypeMarket, quantity: "0.05", lastPrice: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := testSymbolInfo(
+				exchange.SymbolFilter{FilterType: exchange.FilterLotSize, StepSize: dec("0.001")},
+				tt.filter,
+			)
+			cache := NewSymbolCache(&stubSymbolExchange{
+				info:         info,
+				lastPrice:    dec(tt.lastPrice),
+				lastPriceErr: tt.lastPriceErr,
+			})
+			order := &domain.Order{
+				Symbol:   "BTCUSDT",
+				Side:     domain.SideBuy,
+				Type:     tt.orderType,
+				Quantity: dec(tt.quantity),
+			}
+			if tt.price != "" {
+				order.Price = dec(tt.price)
+			}
+			if tt.stopPrice != "" {
+				order.StopPrice = dec(tt.stopPrice)
+			}
+
+			err := cache.Normalize(context.Background(), order)
+			if tt.wantErr && !errors.Is(err, domain.ErrInvalidOrder) {
+				t.Fatalf("Normalize() error = %v, want ErrInvalidOrder", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Normalize() error = %v, want nil", err)
+			}
+		})
+	}
+}
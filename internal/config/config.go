@@ -5,17 +5,24 @@ import (
 	"strings"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	App      AppConfig      `yaml:"app"`
-	Database DatabaseConfig `yaml:"database"`
-	Binance  BinanceConfig  `yaml:"binance"`
-	Kafka    KafkaConfig    `yaml:"kafka"`
-	Outbox   OutboxConfig   `yaml:"outbox"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	App         AppConfig         `yaml:"app"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Exchange    ExchangeConfig    `yaml:"exchange"`
+	Binance     BinanceConfig     `yaml:"binance"`
+	Kafka       KafkaConfig       `yaml:"kafka"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Risk        RiskConfig        `yaml:"risk"`
+	KillSwitch  KillSwitchConfig  `yaml:"kill_switch"`
+	SymbolInfo  SymbolInfoConfig  `yaml:"symbol_info"`
+	Reconciler  ReconcilerConfig  `yaml:"reconciler"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Logging     LoggingConfig     `yaml:"logging"`
 }
 
 // AppConfig holds application settings
@@ -46,9 +53,34 @@ func (d *DatabaseConfig) DSN() string {
 		" sslmode=" + d.SSLMode
 }
 
+// ExchangeConfig holds trading venue selection
+type ExchangeConfig struct {
+	Venue string      `yaml:"venue"`
+	Paper PaperConfig `yaml:"paper"`
+}
+
+// PaperConfig holds settings for the in-process paper trading venue
+type PaperConfig struct {
+	FeeRate        float64            `yaml:"fee_rate"`
+	DepthLevels    int                `yaml:"depth_levels"`
+	LevelQuantity  float64            `yaml:"level_quantity"`
+	LevelSpreadBps float64            `yaml:"level_spread_bps"`
+	Prices         map[string]float64 `yaml:"prices"`
+	Balances       map[string]float64 `yaml:"balances"`
+}
+
+// VenueName returns the configured venue, defaulting to binance-testnet
+func (e *ExchangeConfig) VenueName() string {
+	if e.Venue == "" {
+		return "binance-testnet"
+	}
+	return e.Venue
+}
+
 // BinanceConfig holds Binance API settings
 type BinanceConfig struct {
 	Testnet BinanceTestnetConfig `yaml:"testnet"`
+	Spot    BinanceSpotConfig    `yaml:"spot"`
 }
 
 // BinanceTestnetConfig holds testnet-specific settings
@@ -57,32 +89,348 @@ type BinanceTestnetConfig struct {
 	APIKey    string `yaml:"api_key"`
 	APISecret string `yaml:"api_secret"`
 	BaseURL   string `yaml:"base_url"`
+	StreamURL string `yaml:"stream_url"`
+}
+
+// BinanceSpotConfig holds production spot settings
+type BinanceSpotConfig struct {
+	APIKey    string `yaml:"api_key"`
+	APISecret string `yaml:"api_secret"`
+	BaseURL   string `yaml:"base_url"`
+	StreamURL string `yaml:"stream_url"`
 }
 
 // KafkaConfig holds Kafka connection settings
 type KafkaConfig struct {
-	Brokers       []string          `yaml:"brokers"`
-	ConsumerGroup string            `yaml:"consumer_group"`
-	Topics        KafkaTopicsConfig `yaml:"topics"`
+	Brokers       []string            `yaml:"brokers"`
+	ConsumerGroup string              `yaml:"consumer_group"`
+	Topics        KafkaTopicsConfig   `yaml:"topics"`
+	Retry         RetryConfig         `yaml:"retry"`
+	Producer      KafkaProducerConfig `yaml:"producer"`
+	TLS           KafkaTLSConfig      `yaml:"tls"`
+	SASL          KafkaSASLConfig     `yaml:"sasl"`
+	CloudEvents   CloudEventsConfig   `yaml:"cloudevents"`
+	Serialization SerializationConfig `yaml:"serialization"`
+	// ConsumerConcurrency is how many order messages are handled at once
+	ConsumerConcurrency int `yaml:"consumer_concurrency"`
+	// MockLoopback makes the mock pool deliver published orders to its own consumer
+	MockLoopback bool `yaml:"mock_loopback"`
+}
+
+// Concurrency returns how many order messages are handled at once, defaulting to 10
+func (k *KafkaConfig) Concurrency() int {
+	if k.ConsumerConcurrency <= 0 {
+		return 10
+	}
+	return k.ConsumerConcurrency
 }
 
 // KafkaTopicsConfig holds Kafka topic names
 type KafkaTopicsConfig struct {
-	Orders string `yaml:"orders"`
-	Events string `yaml:"events"`
+	Orders    string `yaml:"orders"`
+	Events    string `yaml:"events"`
+	OrdersDLQ string `yaml:"orders_dlq"`
+}
+
+// KafkaTLSConfig holds TLS settings for broker connections
+type KafkaTLSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CAFile verifies the brokers instead of the system roots when set
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile hold the client certificate for mutual TLS
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
 }
 
+// SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// KafkaSASLConfig holds SASL authentication settings; an empty mechanism disables SASL
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// Producer acknowledgement levels
+const (
+	AcksAll  = "all"
+	AcksOne  = "one"
+	AcksNone = "none"
+)
+
+// KafkaProducerConfig holds Kafka producer settings. Zero values keep the kafka-go
+// defaults, except that acks default to "all" and the batch timeout to 10ms.
+type KafkaProducerConfig struct {
+	// RequiredAcks is "all", "one" or "none"
+	RequiredAcks string `yaml:"required_acks"`
+	// Compression is "none", "gzip", "snappy", "lz4" or "zstd"
+	Compression    string `yaml:"compression"`
+	MaxAttempts    int    `yaml:"max_attempts"`
+	BatchSize      int    `yaml:"batch_size"`
+	BatchBytes     int64  `yaml:"batch_bytes"`
+	BatchTimeoutMs int    `yaml:"batch_timeout_ms"`
+	WriteTimeoutMs int    `yaml:"write_timeout_ms"`
+	// Async returns from writes immediately and reports delivery once the broker
+	// has acknowledged the messages
+	Async bool `yaml:"async"`
+}
+
+// Acks returns the configured acknowledgement level, defaulting to all
+func (p *KafkaProducerConfig) Acks() string {
+	if p.RequiredAcks == "" {
+		return AcksAll
+	}
+	return p.RequiredAcks
+}
+
+// BatchTimeout returns how long the producer waits to fill a batch, defaulting to 10ms
+func (p *KafkaProducerConfig) BatchTimeout() time.Duration {
+	if p.BatchTimeoutMs <= 0 {
+		return 10 * time.Millisecond
+	}
+	return time.Duration(p.BatchTimeoutMs) * time.Millisecond
+}
+
+// WriteTimeout returns the produce request timeout as a time.Duration
+func (p *KafkaProducerConfig) WriteTimeout() time.Duration {
+	return time.Duration(p.WriteTimeoutMs) * time.Millisecond
+}
+
+// CloudEvents content modes
+const (
+	// CloudEventsModeBinary carries the event attributes in ce_* headers and the data as the message value
+	CloudEventsModeBinary = "binary"
+	// CloudEventsModeStructured carries the whole CloudEvents JSON envelope as the message value
+	CloudEventsModeStructured = "structured"
+)
+
+// CloudEventsConfig holds the CloudEvents envelope settings for published messages
+type CloudEventsConfig struct {
+	Mode   string `yaml:"mode"`
+	Source string `yaml:"source"`
+}
+
+// SerializationConfig holds the event payload serialization settings
+type SerializationConfig struct {
+	// Format is "json", "protobuf" or "avro"
+	Format string `yaml:"format"`
+	// RegistryDir is where the local schema registry keeps registered schemas
+	RegistryDir string `yaml:"registry_dir"`
+}
+
+// RetryConfig holds an exponential backoff retry policy
+type RetryConfig struct {
+	MaxAttempts      int `yaml:"max_attempts"`
+	InitialBackoffMs int `yaml:"initial_backoff_ms"`
+	MaxBackoffMs     int `yaml:"max_backoff_ms"`
+}
+
+// Backoff returns the delay before the given retry attempt (1-based), doubling
+// from the initial backoff up to the maximum
+func (r *RetryConfig) Backoff(attempt int) time.Duration {
+	backoff := time.Duration(r.InitialBackoffMs) * time.Millisecond
+	maxBackoff := time.Duration(r.MaxBackoffMs) * time.Millisecond
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// Outbox relay modes
+const (
+	// OutboxModePoll polls the outbox table every poll interval
+	OutboxModePoll = "poll"
+	// OutboxModeNotify wakes the relay on Postgres notifications and polls only while disconnected
+	OutboxModeNotify = "notify"
+)
+
 // OutboxConfig holds Outbox Relay settings
 type OutboxConfig struct {
-	PollIntervalMs int `yaml:"poll_interval_ms"`
-	BatchSize      int `yaml:"batch_size"`
+	Mode            string              `yaml:"mode"`
+	PollIntervalMs  int                 `yaml:"poll_interval_ms"`
+	SweepIntervalMs int                 `yaml:"sweep_interval_ms"`
+	BatchSize       int                 `yaml:"batch_size"`
+	LeaseMs         int                 `yaml:"lease_ms"`
+	Retry           RetryConfig         `yaml:"retry"`
+	Janitor         OutboxJanitorConfig `yaml:"janitor"`
+	Routes          []OutboxRoute       `yaml:"routes"`
+}
+
+// OutboxRoute sends events matching an event type and/or aggregate to a topic.
+// Empty match fields match any value.
+type OutboxRoute struct {
+	EventType string `yaml:"event_type"`
+	Aggregate string `yaml:"aggregate"`
+	Topic     string `yaml:"topic"`
 }
 
 // PollInterval returns the poll interval as a time.Duration
 func (o *OutboxConfig) PollInterval() time.Duration {
+	if o.PollIntervalMs <= 0 {
+		return 500 * time.Millisecond
+	}
 	return time.Duration(o.PollIntervalMs) * time.Millisecond
 }
 
+// SweepInterval returns how often a notify-mode relay still polls while connected,
+// to pick up retried events and expired leases that trigger no notification
+func (o *OutboxConfig) SweepInterval() time.Duration {
+	if o.SweepIntervalMs <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(o.SweepIntervalMs) * time.Millisecond
+}
+
+// TopicFor returns the topic of the first route matching the event, or "" if none does
+func (o *OutboxConfig) TopicFor(aggregate, eventType string) string {
+	for _, r := range o.Routes {
+		if (r.EventType == "" || r.EventType == eventType) && (r.Aggregate == "" || r.Aggregate == aggregate) {
+			return r.Topic
+		}
+	}
+	return ""
+}
+
+// Lease returns how long a relay holds claimed events before other instances may take them over
+func (o *OutboxConfig) Lease() time.Duration {
+	if o.LeaseMs <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(o.LeaseMs) * time.Millisecond
+}
+
+// OutboxJanitorConfig holds the retention policy for processed outbox events
+type OutboxJanitorConfig struct {
+	IntervalMs     int  `yaml:"interval_ms"`
+	RetentionHours int  `yaml:"retention_hours"`
+	BatchSize      int  `yaml:"batch_size"`
+	Archive        bool `yaml:"archive"`
+}
+
+// Interval returns how often the janitor runs as a time.Duration, defaulting to 10m
+func (j *OutboxJanitorConfig) Interval() time.Duration {
+	if j.IntervalMs <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(j.IntervalMs) * time.Millisecond
+}
+
+// Retention returns how long processed events are kept as a time.Duration
+func (j *OutboxJanitorConfig) Retention() time.Duration {
+	return time.Duration(j.RetentionHours) * time.Hour
+}
+
+// RiskConfig holds the pre-trade risk limits. Symbols without an entry use Default.
+type RiskConfig struct {
+	Enabled bool                  `yaml:"enabled" json:"enabled"`
+	Account AccountRiskLimits     `yaml:"account" json:"account"`
+	Default RiskLimits            `yaml:"default" json:"default"`
+	Symbols map[string]RiskLimits `yaml:"symbols" json:"symbols"`
+}
+
+// AccountRiskLimits holds limits applied across all symbols; zero disables a limit.
+// Amounts are exact decimals, like the order values they are compared with.
+type AccountRiskLimits struct {
+	MaxOrderNotional   decimal.Decimal `yaml:"max_order_notional" json:"max_order_notional"`
+	MaxOrdersPerMinute int             `yaml:"max_orders_per_minute" json:"max_orders_per_minute"`
+}
+
+// RiskLimits holds the limits of a symbol; zero disables a limit
+type RiskLimits struct {
+	// MaxOrderNotional caps quantity times price of a single order, in quote currency
+	MaxOrderNotional decimal.Decimal `yaml:"max_order_notional" json:"max_order_notional"`
+	// MaxPosition caps the absolute position, in base currency, if all open orders filled
+	MaxPosition        decimal.Decimal `yaml:"max_position" json:"max_position"`
+	MaxOrdersPerMinute int             `yaml:"max_orders_per_minute" json:"max_orders_per_minute"`
+	// PriceBandBps caps how far a limit price may be from the last trade price
+	PriceBandBps decimal.Decimal `yaml:"price_band_bps" json:"price_band_bps"`
+}
+
+// LimitsFor returns the limits of a symbol
+func (r *RiskConfig) LimitsFor(symbol string) RiskLimits {
+	if limits, ok := r.Symbols[symbol]; ok {
+		return limits
+	}
+	return r.Default
+}
+
+// KillSwitchConfig holds the kill switch settings
+type KillSwitchConfig struct {
+	// Engaged halts trading on every instance when this one starts
+	Engaged bool `yaml:"engaged"`
+	// CancelOpenOrders cancels all open orders when the switch is engaged at startup
+	CancelOpenOrders bool `yaml:"cancel_open_orders"`
+	// PollIntervalMs is how often the shared switch is re-read to hold or resume queued orders
+	PollIntervalMs int `yaml:"poll_interval_ms"`
+}
+
+// PollInterval returns how often the shared kill switch state is re-read
+func (k *KillSwitchConfig) PollInterval() time.Duration {
+	if k.PollIntervalMs <= 0 {
+		return time.Second
+	}
+	return time.Duration(k.PollIntervalMs) * time.Millisecond
+}
+
+// SymbolInfoConfig holds settings of the cached exchange symbol metadata
+type SymbolInfoConfig struct {
+	RefreshIntervalMs int `yaml:"refresh_interval_ms"`
+}
+
+// RefreshInterval returns how often cached symbol metadata is reloaded from the exchange
+func (s *SymbolInfoConfig) RefreshInterval() time.Duration {
+	if s.RefreshIntervalMs <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(s.RefreshIntervalMs) * time.Millisecond
+}
+
+// ReconcilerConfig holds order status reconciliation settings
+type ReconcilerConfig struct {
+	IntervalMs   int `yaml:"interval_ms"`
+	StaleAfterMs int `yaml:"stale_after_ms"`
+	BatchSize    int `yaml:"batch_size"`
+}
+
+// Interval returns the reconciliation interval as a time.Duration, defaulting to 1m
+func (r *ReconcilerConfig) Interval() time.Duration {
+	if r.IntervalMs <= 0 {
+		return time.Minute
+	}
+	return time.Duration(r.IntervalMs) * time.Millisecond
+}
+
+// StaleAfter returns how long an order may stay in flight before it is reconciled,
+// defaulting to 2m
+func (r *ReconcilerConfig) StaleAfter() time.Duration {
+	if r.StaleAfterMs <= 0 {
+		return 2 * time.Minute
+	}
+	return time.Duration(r.StaleAfterMs) * time.Millisecond
+}
+
+// IdempotencyConfig holds idempotent order submission settings
+type IdempotencyConfig struct {
+	RetentionHours int `yaml:"retention_hours"`
+}
+
+// Retention returns how long Idempotency-Key fingerprints are kept
+func (i *IdempotencyConfig) Retention() time.Duration {
+	if i.RetentionHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(i.RetentionHours) * time.Hour
+}
+
 // LoggingConfig holds logging settings
 type LoggingConfig struct {
 	Level  string `yaml:"level"`
@@ -111,7 +459,11 @@ func Load(path string) (*Config, error) {
 func expandEnvVars(cfg *Config) {
 	cfg.Binance.Testnet.APIKey = expandEnvVar(cfg.Binance.Testnet.APIKey)
 	cfg.Binance.Testnet.APISecret = expandEnvVar(cfg.Binance.Testnet.APISecret)
+	cfg.Binance.Spot.APIKey = expandEnvVar(cfg.Binance.Spot.APIKey)
+	cfg.Binance.Spot.APISecret = expandEnvVar(cfg.Binance.Spot.APISecret)
 	cfg.Database.Password = expandEnvVar(cfg.Database.Password)
+	cfg.Kafka.SASL.Username = expandEnvVar(cfg.Kafka.SASL.Username)
+	cfg.Kafka.SASL.Password = expandEnvVar(cfg.Kafka.SASL.Password)
 }
 
 // expandEnvVar expands a single environment variable
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EventSchemaVersion is the payload schema version written for new outbox events
+const EventSchemaVersion = 2
+
+// OrderEventV1 is version 1 of the payload of order lifecycle events: a snapshot of
+// the order after the change. The event tags give the stable field numbers used by
+// binary serializers; a field may only be added as optional, and never renumbered.
+// Amounts are doubles in this version, converted from the order's exact decimals;
+// version 2 replaces them with exact decimal strings.
+type OrderEventV1 struct {
+	ID                 string      `json:"id" event:"1"`
+	Symbol             string      `json:"symbol" event:"2"`
+	Side               OrderSide   `json:"side" event:"3"`
+	Type               OrderType   `json:"type" event:"4"`
+	Quantity           float64     `json:"quantity" event:"5"`
+	Price              float64     `json:"price" event:"6"`
+	Status             OrderStatus `json:"status" event:"7"`
+	ExecutedQuantity   float64     `json:"executed_quantity" event:"8"`
+	CumulativeQuoteQty float64     `json:"cumulative_quote_quantity" event:"9"`
+	AvgFillPrice       float64     `json:"avg_fill_price" event:"10"`
+	CreatedAt          time.Time   `json:"created_at" event:"11"`
+	UpdatedAt          time.Time   `json:"updated_at" event:"12"`
+}
+
+// OrderReconciledV1 is version 1 of the payload of OrderReconciled events
+type OrderReconciledV1 struct {
+	OrderEventV1
+	PreviousStatus           OrderStatus `json:"previous_status" event:"13"`
+	PreviousExecutedQuantity float64     `json:"previous_executed_quantity" event:"14"`
+	ExchangeStatus           string      `json:"exchange_status" event:"15"`
+}
+
+// OrderRejectedV1 is version 1 of the payload of OrderRejected events
+type OrderRejectedV1 struct {
+	OrderEventV1
+	RejectReason string `json:"reject_reason" event:"13"`
+}
+
+// OrderEventV2 is version 2 of the payload of order lifecycle events. Amounts are
+// the order's exact decimals, carried as strings under new names and numbers; the
+// doubles of version 1 are dropped. The amounts are optional only because version
+// 1 events lack them. Numbers 13 to 15 and 21 are taken by the event-specific
+// fields. Stop orders also carry their trigger: stop_price_decimal and, for
+// trailing stops, trailing_delta in basis points; both are zero otherwise.
+type OrderEventV2 struct {
+	ID                 string          `json:"id" event:"1"`
+	Symbol             string          `json:"symbol" event:"2"`
+	Side               OrderSide       `json:"side" event:"3"`
+	Type               OrderType       `json:"type" event:"4"`
+	Status             OrderStatus     `json:"status" event:"7"`
+	CreatedAt          time.Time       `json:"created_at" event:"11"`
+	UpdatedAt          time.Time       `json:"updated_at" event:"12"`
+	Quantity           decimal.Decimal `json:"quantity_decimal" event:"16,optional"`
+	Price              decimal.Decimal `json:"price_decimal" event:"17,optional"`
+	ExecutedQuantity   decimal.Decimal `json:"executed_quantity_decimal" event:"18,optional"`
+	CumulativeQuoteQty decimal.Decimal `json:"cumulative_quote_quantity_decimal" event:"19,optional"`
+	AvgFillPrice       decimal.Decimal `json:"avg_fill_price_decimal" event:"20,optional"`
+	StopPrice          decimal.Decimal `json:"stop_price_decimal" event:"22,optional"`
+	TrailingDelta      int64           `json:"trailing_delta" event:"23,optional"`
+}
+
+// OrderReconciledV2 is version 2 of the payload of OrderReconciled events
+type OrderReconciledV2 struct {
+	OrderEventV2
+	PreviousStatus           OrderStatus     `json:"previous_status" event:"13"`
+	ExchangeStatus           string          `json:"exchange_status" event:"15"`
+	PreviousExecutedQuantity decimal.Decimal `json:"previous_executed_quantity_decimal" event:"21,optional"`
+}
+
+// OrderRejectedV2 is version 2 of the payload of OrderRejected events
+type OrderRejectedV2 struct {
+	OrderEventV2
+	RejectReason string `json:"reject_reason" event:"13"`
+}
+
+// NewOrderEventV2 creates a version 2 order event payload from an order
+func NewOrderEventV2(order *Order) OrderEventV2 {
+	return OrderEventV2{
+		ID:                 order.ID,
+		Symbol:             order.Symbol,
+		Side:               order.Side,
+		Type:               order.Type,
+		Status:             order.Status,
+		CreatedAt:          order.CreatedAt,
+		UpdatedAt:          order.UpdatedAt,
+		Quantity:           order.Quantity,
+		Price:              order.Price,
+		ExecutedQuantity:   order.ExecutedQuantity,
+		CumulativeQuoteQty: order.CumulativeQuoteQty,
+		AvgFillPrice:       order.AvgFillPrice,
+		StopPrice:          order.StopPrice,
+		TrailingDelta:      order.TrailingDelta,
+	}
+}
+
+// Order returns the order the payload is a snapshot of
+func (e *OrderEventV2) Order() *Order {
+	return &Order{
+		ID:                 e.ID,
+		Symbol:             e.Symbol,
+		Side:               e.Side,
+		Type:               e.Type,
+		Quantity:           e.Quantity,
+		Price:              e.Price,
+		StopPrice:          e.StopPrice,
+		TrailingDelta:      e.TrailingDelta,
+		Status:             e.Status,
+		ExecutedQuantity:   e.ExecutedQuantity,
+		CumulativeQuoteQty: e.CumulativeQuoteQty,
+		AvgFillPrice:       e.AvgFillPrice,
+		CreatedAt:          e.CreatedAt,
+		UpdatedAt:          e.UpdatedAt,
+	}
+}
+
+// OrderFromEvent rebuilds an order from the JSON payload of an order event of the
+// given schema version. Version 1 field names match the order's JSON fields.
+func OrderFromEvent(version int, payload []byte) (*Order, error) {
+	switch version {
+	case 1:
+		var order Order
+		if err := json.Unmarshal(payload, &order); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal order: %w", err)
+		}
+		return &order, nil
+	case 2:
+		var event OrderEventV2
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal order event: %w", err)
+		}
+		return event.Order(), nil
+	}
+	return nil, fmt.Errorf("unsupported order event version %d", version)
+}
+
+// EventPayloadsV1 maps each event type to its version 1 payload type
+var EventPayloadsV1 = map[string]interface{}{
+	EventOrderSubmitted:       OrderEventV1{},
+	EventOrderExecuting:       OrderEventV1{},
+	EventOrderPartiallyFilled: OrderEventV1{},
+	EventOrderCompleted:       OrderEventV1{},
+	EventOrderFailed:          OrderEventV1{},
+	EventOrderCancelled:       OrderEventV1{},
+	EventOrderReconciled:      OrderReconciledV1{},
+	EventOrderRejected:        OrderRejectedV1{},
+	EventKillSwitchEngaged:    KillSwitchToggledV1{},
+	EventKillSwitchReleased:   KillSwitchToggledV1{},
+}
+
+// EventPayloadsV2 maps each event type to its version 2 payload type
+var EventPayloadsV2 = map[string]interface{}{
+	EventOrderSubmitted:       OrderEventV2{},
+	EventOrderExecuting:       OrderEventV2{},
+	EventOrderPartiallyFilled: OrderEventV2{},
+	EventOrderCompleted:       OrderEventV2{},
+	EventOrderFailed:          OrderEventV2{},
+	EventOrderCancelled:       OrderEventV2{},
+	EventOrderReconciled:      OrderReconciledV2{},
+	EventOrderRejected:        OrderRejectedV2{},
+	EventKillSwitchEngaged:    KillSwitchToggledV1{},
+	EventKillSwitchReleased:   KillSwitchToggledV1{},
+}
+
+// EventPayloads maps each published schema version to its payload types
+var EventPayloads = map[int]map[string]interface{}{
+	1: EventPayloadsV1,
+	2: EventPayloadsV2,
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// cryptoOrder returns an order with amounts typical of crypto venues, several of
+// which a float64 cannot hold exactly
+func cryptoOrder() *Order {
+	order := NewOrder("order-1", "BTCUSDT", SideBuy, TypeStopLossLimit,
+		decimal.RequireFromString("1234567890.12345678"), decimal.RequireFromString("64250.12345678"))
+	order.StopPrice = decimal.RequireFromString("64300.00000001")
+	order.TrailingDelta = 150
+	order.Status = StatusPartiallyFilled
+	order.ExecutedQuantity = decimal.RequireFromString("0.00000001")
+	order.CumulativeQuoteQty = decimal.RequireFromString("0.00064250")
+	order.AvgFillPrice = decimal.RequireFromString("64250.1")
+	order.CreatedAt = time.Date(2026, 10, 16, 9, 30, 0, 123000000, time.UTC)
+	order.UpdatedAt = order.CreatedAt.Add(time.Second)
+	return order
+}
+
+// assertSameAmounts fails unless every amount of got equals the one of want exactly
+func assertSameAmounts(t *testing.T, want, got *Order) {
+	t.Helper()
+	amounts := []struct {
+		name      string
+		want, got decimal.Decimal
+	}{
+		{"quantity", want.Quantity, got.Quantity},
+		{"price", want.Price, got.Price},
+		{"executed quantity", want.ExecutedQuantity, got.ExecutedQuantity},
+		{"cumulative quote quantity", want.CumulativeQuoteQty, got.CumulativeQuoteQty},
+		{"average fill price", want.AvgFillPrice, got.AvgFillPrice},
+		{"stop price", want.StopPrice, got.StopPrice},
+	}
+	for _, a := range amounts {
+		if !a.got.Equal(a.want) {
+			t.Errorf("%s: expected %s, got %s", a.name, a.want, a.got)
+		}
+	}
+}
+
+func TestOrderJSONRoundTripKeepsDecimals(t *testing.T) {
+	order := cryptoOrder()
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatalf("failed to marshal order: %v", err)
+	}
+	var decoded Order
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal order: %v", err)
+	}
+	assertSameAmounts(t, order, &decoded)
+}
+
+func TestOrderEventV2RoundTripKeepsDecimals(t *testing.T) {
+	order := cryptoOrder()
+
+	data, err := json.Marshal(NewOrderEventV2(order))
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	decoded, err := OrderFromEvent(2, data)
+	if err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+
+	assertSameAmounts(t, order, decoded)
+	if decoded.ID != order.ID || decoded.Status != order.Status || decoded.TrailingDelta != order.TrailingDelta ||
+		!decoded.UpdatedAt.Equal(order.UpdatedAt) {
+		t.Errorf("expected %+v, got %+v", order, decoded)
+	}
+}
+
+func TestOrderReconciledV2CarriesExactPreviousQuantity(t *testing.T) {
+	previous := decimal.RequireFromString("9007199254740993.00000001")
+	data, err := json.Marshal(OrderReconciledV2{
+		OrderEventV2:             NewOrderEventV2(cryptoOrder()),
+		PreviousStatus:           StatusExecuting,
+		ExchangeStatus:           "PARTIALLY_FILLED",
+		PreviousExecutedQuantity: previous,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+
+	var decoded OrderReconciledV2
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+	if !decoded.PreviousExecutedQuantity.Equal(previous) {
+		t.Errorf("expected previous executed quantity %s, got %s", previous, decoded.PreviousExecutedQuantity)
+	}
+}
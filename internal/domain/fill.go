@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fill represents a single trade execution against an order
+type Fill struct {
+	ID              uint64          `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID         string          `json:"order_id" gorm:"size:64;index;uniqueIndex:idx_fills_order_trade"`
+	TradeID         int64           `json:"trade_id" gorm:"uniqueIndex:idx_fills_order_trade"`
+	Price           decimal.Decimal `json:"price" gorm:"type:decimal(20,8)"`
+	Quantity        decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
+	Commission      decimal.Decimal `json:"commission" gorm:"type:decimal(20,8);default:0"`
+	CommissionAsset string          `json:"commission_asset" gorm:"size:20"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
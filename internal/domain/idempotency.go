@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrDuplicateOrder is returned when an order with the same ID already exists
+	ErrDuplicateOrder = errors.New("order already exists")
+	// ErrIdempotencyConflict is returned when an order ID or idempotency key is reused with a different payload
+	ErrIdempotencyConflict = errors.New("idempotency key reused with a different request")
+)
+
+// IdempotencyKey records the request fingerprint submitted under an Idempotency-Key header
+type IdempotencyKey struct {
+	Key         string    `json:"key" gorm:"primaryKey;size:128"`
+	Fingerprint string    `json:"fingerprint" gorm:"size:64"`
+	OrderID     string    `json:"order_id" gorm:"size:64"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+}
+
+// NewIdempotencyKey creates a key record for an order that expires after retention
+func NewIdempotencyKey(key string, order *Order, retention time.Duration) *IdempotencyKey {
+	now := time.Now()
+	return &IdempotencyKey{
+		Key:         key,
+		Fingerprint: order.Fingerprint(),
+		OrderID:     order.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(retention),
+	}
+}
+
+// Fingerprint returns a stable hash of the client-supplied fields of an order.
+// Amounts are rendered with AmountScale places, so 0.1 and 0.10 hash alike. The
+// trigger fields are only included when set, so fingerprints stored for orders
+// without them stay valid.
+func (o *Order) Fingerprint() string {
+	fields := fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+		o.ID, o.Symbol, o.Side, o.Type, o.Quantity.StringFixed(AmountScale), o.Price.StringFixed(AmountScale))
+	if !o.StopPrice.IsZero() || o.TrailingDelta != 0 {
+		fields += fmt.Sprintf("|%s|%d", o.StopPrice.StringFixed(AmountScale), o.TrailingDelta)
+	}
+	sum := sha256.Sum256([]byte(fields))
+	return hex.EncodeToString(sum[:])
+}
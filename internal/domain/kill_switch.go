@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Kill switch audit event types written to the outbox
+const (
+	EventKillSwitchEngaged  = "KillSwitchEngaged"
+	EventKillSwitchReleased = "KillSwitchReleased"
+)
+
+// KillSwitchID is the ID of the single kill switch row shared by every instance
+const KillSwitchID = "global"
+
+// ErrTradingHalted is returned when an order is not placed because the kill switch is engaged
+var ErrTradingHalted = errors.New("trading halted by kill switch")
+
+// KillSwitch is the kill switch state stored in the database, so that every
+// instance halts and resumes trading together
+type KillSwitch struct {
+	ID        string `gorm:"primaryKey;size:16"`
+	Engaged   bool   `gorm:"not null;default:false"`
+	Reason    string `gorm:"size:255"`
+	Source    string `gorm:"size:32"`
+	UpdatedAt time.Time
+}
+
+// KillSwitchToggledV1 is version 1 of the payload of kill switch audit events
+type KillSwitchToggledV1 struct {
+	Reason    string    `json:"reason" event:"1"`
+	Source    string    `json:"source" event:"2"`
+	ToggledAt time.Time `json:"toggled_at" event:"3"`
+}
@@ -1,17 +1,34 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrOrderNotFound is returned when an order does not exist
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidTransition is returned when an order cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	// ErrInvalidOrder is returned when an order breaks the rules of its type or the
+	// trading rules of its symbol
+	ErrInvalidOrder = errors.New("invalid order")
 )
 
 // OrderStatus represents the status of an order
 type OrderStatus string
 
 const (
-	StatusPending   OrderStatus = "PENDING"
-	StatusExecuting OrderStatus = "EXECUTING"
-	StatusCompleted OrderStatus = "COMPLETED"
-	StatusFailed    OrderStatus = "FAILED"
+	StatusPending         OrderStatus = "PENDING"
+	StatusExecuting       OrderStatus = "EXECUTING"
+	StatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	StatusCompleted       OrderStatus = "COMPLETED"
+	StatusFailed          OrderStatus = "FAILED"
+	StatusCancelled       OrderStatus = "CANCELLED"
+	StatusRejected        OrderStatus = "REJECTED"
 )
 
 // OrderSide represents the side of an order
@@ -26,37 +43,97 @@ const (
 type OrderType string
 
 const (
-	TypeMarket OrderType = "MARKET"
-	TypeLimit  OrderType = "LIMIT"
+	TypeMarket          OrderType = "MARKET"
+	TypeLimit           OrderType = "LIMIT"
+	TypeLimitMaker      OrderType = "LIMIT_MAKER"
+	TypeStopLoss        OrderType = "STOP_LOSS"
+	TypeStopLossLimit   OrderType = "STOP_LOSS_LIMIT"
+	TypeTakeProfit      OrderType = "TAKE_PROFIT"
+	TypeTakeProfitLimit OrderType = "TAKE_PROFIT_LIMIT"
 )
 
-// Order represents a trading order
+// HasLimitPrice reports whether orders of this type carry a limit price
+func (t OrderType) HasLimitPrice() bool {
+	switch t {
+	case TypeLimit, TypeLimitMaker, TypeStopLossLimit, TypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// IsConditional reports whether orders of this type wait for a stop price or
+// trailing delta to trigger before they execute
+func (t OrderType) IsConditional() bool {
+	switch t {
+	case TypeStopLoss, TypeStopLossLimit, TypeTakeProfit, TypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the type is one of the supported order types
+func (t OrderType) IsKnown() bool {
+	return t == TypeMarket || t.HasLimitPrice() || t.IsConditional()
+}
+
+// AmountScale is the number of decimal places prices and quantities are stored with
+const AmountScale = 8
+
+// Order represents a trading order. Prices and quantities are exact decimals and
+// are rendered as JSON strings, so no precision is lost on the way to the exchange.
 type Order struct {
-	ID        string      `json:"id" gorm:"primaryKey;size:64"`
-	Symbol    string      `json:"symbol" gorm:"size:20;index"`
-	Side      OrderSide   `json:"side" gorm:"size:10"`
-	Type      OrderType   `json:"type" gorm:"size:10"`
-	Quantity  float64     `json:"quantity" gorm:"type:decimal(20,8)"`
-	Price     float64     `json:"price" gorm:"type:decimal(20,8);default:0"`
-	Status    OrderStatus `json:"status" gorm:"size:20;index"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID                 string          `json:"id" gorm:"primaryKey;size:64"`
+	Symbol             string          `json:"symbol" gorm:"size:20;index"`
+	Side               OrderSide       `json:"side" gorm:"size:10"`
+	Type               OrderType       `json:"type" gorm:"size:20"`
+	Quantity           decimal.Decimal `json:"quantity" gorm:"type:decimal(20,8)"`
+	Price              decimal.Decimal `json:"price" gorm:"type:decimal(20,8);default:0"`
+	StopPrice          decimal.Decimal `json:"stop_price" gorm:"type:decimal(20,8);default:0"`
+	TrailingDelta      int64           `json:"trailing_delta,omitempty" gorm:"default:0"`
+	Status             OrderStatus     `json:"status" gorm:"size:20;index"`
+	ExecutedQuantity   decimal.Decimal `json:"executed_quantity" gorm:"type:decimal(20,8);default:0"`
+	CumulativeQuoteQty decimal.Decimal `json:"cumulative_quote_quantity" gorm:"type:decimal(20,8);default:0"`
+	AvgFillPrice       decimal.Decimal `json:"avg_fill_price" gorm:"type:decimal(20,8);default:0"`
+	RejectReason       string          `json:"reject_reason,omitempty" gorm:"size:32"`
+	CorrelationID      string          `json:"correlation_id,omitempty" gorm:"size:64"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
 }
 
-// OutboxEvent represents an event to be published to Kafka
-type OutboxEvent struct {
-	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	Aggregate   string     `json:"aggregate" gorm:"size:100;index"`
-	AggregateID string     `json:"aggregate_id" gorm:"size:64;index"`
-	EventType   string     `json:"event_type" gorm:"size:100"`
-	Payload     string     `json:"payload" gorm:"type:text"`
-	Processed   bool       `json:"processed" gorm:"default:false;index"`
-	CreatedAt   time.Time  `json:"created_at"`
-	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+// Order event types written to the outbox
+const (
+	EventOrderSubmitted       = "OrderSubmitted"
+	EventOrderExecuting       = "OrderExecuting"
+	EventOrderPartiallyFilled = "OrderPartiallyFilled"
+	EventOrderCompleted       = "OrderCompleted"
+	EventOrderFailed          = "OrderFailed"
+	EventOrderCancelled       = "OrderCancelled"
+	EventOrderReconciled      = "OrderReconciled"
+	EventOrderRejected        = "OrderRejected"
+)
+
+// OrderEventType returns the event type recording an order reaching the given status
+func OrderEventType(status OrderStatus) string {
+	switch status {
+	case StatusExecuting:
+		return EventOrderExecuting
+	case StatusPartiallyFilled:
+		return EventOrderPartiallyFilled
+	case StatusCompleted:
+		return EventOrderCompleted
+	case StatusFailed:
+		return EventOrderFailed
+	case StatusCancelled:
+		return EventOrderCancelled
+	case StatusRejected:
+		return EventOrderRejected
+	default:
+		return EventOrderSubmitted
+	}
 }
 
 // NewOrder creates a new order with PENDING status
-func NewOrder(id, symbol string, side OrderSide, orderType OrderType, quantity, price float64) *Order {
+func NewOrder(id, symbol string, side OrderSide, orderType OrderType, quantity, price decimal.Decimal) *Order {
 	now := time.Now()
 	return &Order{
 		ID:        id,
@@ -71,18 +148,63 @@ func NewOrder(id, symbol string, side OrderSide, orderType OrderType, quantity,
 	}
 }
 
-// IsValid checks if the order is valid
-func (o *Order) IsValid() bool {
-	return o.ID != "" && o.Symbol != "" && o.Quantity > 0
+// Validate checks the order against the rules of its type and returns an error
+// wrapping ErrInvalidOrder naming the first rule it breaks:
+//   - LIMIT, LIMIT_MAKER, STOP_LOSS_LIMIT and TAKE_PROFIT_LIMIT need a price; other types must not set one
+//   - STOP_LOSS, STOP_LOSS_LIMIT, TAKE_PROFIT and TAKE_PROFIT_LIMIT need a stop price,
+//     a trailing delta in basis points, or both; other types must set neither
+func (o *Order) Validate() error {
+	if o.ID == "" || o.Symbol == "" {
+		return fmt.Errorf("%w: id and symbol are required", ErrInvalidOrder)
+	}
+	if o.Side != SideBuy && o.Side != SideSell {
+		return fmt.Errorf("%w: side must be BUY or SELL", ErrInvalidOrder)
+	}
+	if !o.Type.IsKnown() {
+		return fmt.Errorf("%w: unknown order type %q", ErrInvalidOrder, o.Type)
+	}
+	if !o.Quantity.IsPositive() {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidOrder)
+	}
+
+	if o.Type.HasLimitPrice() {
+		if !o.Price.IsPositive() {
+			return fmt.Errorf("%w: %s orders require a positive price", ErrInvalidOrder, o.Type)
+		}
+	} else if !o.Price.IsZero() {
+		return fmt.Errorf("%w: %s orders must not set a price", ErrInvalidOrder, o.Type)
+	}
+
+	if o.StopPrice.IsNegative() || o.TrailingDelta < 0 {
+		return fmt.Errorf("%w: stop price and trailing delta must not be negative", ErrInvalidOrder)
+	}
+	hasTrigger := o.StopPrice.IsPositive() || o.TrailingDelta > 0
+	if o.Type.IsConditional() && !hasTrigger {
+		return fmt.Errorf("%w: %s orders require a stop price or trailing delta", ErrInvalidOrder, o.Type)
+	}
+	if !o.Type.IsConditional() && hasTrigger {
+		return fmt.Errorf("%w: %s orders must not set a stop price or trailing delta", ErrInvalidOrder, o.Type)
+	}
+	return nil
+}
+
+// Reject marks an order that failed pre-trade checks as REJECTED with a reason code
+func (o *Order) Reject(reason string) {
+	o.Status = StatusRejected
+	o.RejectReason = reason
+	o.UpdatedAt = time.Now()
 }
 
 // CanTransitionTo checks if the order can transition to the given status
 func (o *Order) CanTransitionTo(newStatus OrderStatus) bool {
 	transitions := map[OrderStatus][]OrderStatus{
-		StatusPending:   {StatusExecuting, StatusFailed},
-		StatusExecuting: {StatusCompleted, StatusFailed},
-		StatusCompleted: {},
-		StatusFailed:    {},
+		StatusPending:         {StatusExecuting, StatusFailed, StatusCancelled},
+		StatusExecuting:       {StatusPartiallyFilled, StatusCompleted, StatusFailed, StatusCancelled},
+		StatusPartiallyFilled: {StatusPartiallyFilled, StatusCompleted, StatusCancelled},
+		StatusCompleted:       {},
+		StatusFailed:          {},
+		StatusCancelled:       {},
+		StatusRejected:        {},
 	}
 
 	for _, allowed := range transitions[o.Status] {
@@ -92,3 +214,19 @@ func (o *Order) CanTransitionTo(newStatus OrderStatus) bool {
 	}
 	return false
 }
+
+// ApplyExecution records the cumulative executed totals reported by the exchange
+// and recomputes the average fill price, rounded to AmountScale decimal places
+func (o *Order) ApplyExecution(executedQty, cumulativeQuoteQty decimal.Decimal) {
+	o.ExecutedQuantity = executedQty
+	o.CumulativeQuoteQty = cumulativeQuoteQty
+	if executedQty.IsPositive() {
+		o.AvgFillPrice = cumulativeQuoteQty.DivRound(executedQty, AmountScale)
+	}
+	o.UpdatedAt = time.Now()
+}
+
+// RemainingQuantity returns the quantity that has not been filled yet
+func (o *Order) RemainingQuantity() decimal.Decimal {
+	return o.Quantity.Sub(o.ExecutedQuantity)
+}
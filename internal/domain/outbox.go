@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOutboxEventNotFound is returned when an outbox event does not exist
+var ErrOutboxEventNotFound = errors.New("outbox event not found")
+
+// OutboxEvent represents an event to be published to Kafka
+type OutboxEvent struct {
+	ID            uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Aggregate     string     `json:"aggregate" gorm:"size:100;index"`
+	AggregateID   string     `json:"aggregate_id" gorm:"size:64;index"`
+	EventType     string     `json:"event_type" gorm:"size:100"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	SchemaVersion int        `json:"schema_version" gorm:"default:1"`
+	Processed     bool       `json:"processed" gorm:"default:false;index"`
+	CorrelationID string     `json:"correlation_id,omitempty" gorm:"size:64"`
+	CausationID   string     `json:"causation_id,omitempty" gorm:"size:64"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	Quarantined   bool       `json:"quarantined" gorm:"default:false;index"`
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+	LockedBy      string     `json:"locked_by,omitempty" gorm:"size:128"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+}
+
+// ArchivedOutboxEvent is a processed outbox event moved out of the live table by the janitor
+type ArchivedOutboxEvent struct {
+	OutboxEvent
+	ArchivedAt time.Time `json:"archived_at" gorm:"index"`
+}
+
+// TableName overrides the table name used by ArchivedOutboxEvent
+func (ArchivedOutboxEvent) TableName() string {
+	return "outbox_events_archive"
+}
+
+// RecordFailure counts a failed publish attempt. The event is scheduled for
+// another attempt after backoff, or quarantined once maxAttempts is reached.
+func (e *OutboxEvent) RecordFailure(cause error, maxAttempts int, backoff time.Duration) {
+	now := time.Now()
+	e.Attempts++
+	e.LastError = cause.Error()
+
+	if maxAttempts > 0 && e.Attempts >= maxAttempts {
+		e.Quarantined = true
+		e.QuarantinedAt = &now
+		e.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(backoff)
+	e.NextAttemptAt = &next
+}
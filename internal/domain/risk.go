@@ -0,0 +1,29 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+// Reason codes of orders rejected by pre-trade risk checks or the kill switch
+const (
+	RejectMaxNotional      = "MAX_NOTIONAL"
+	RejectMaxPosition      = "MAX_POSITION"
+	RejectOrderRate        = "ORDER_RATE"
+	RejectPriceBand        = "PRICE_BAND"
+	RejectPriceUnavailable = "PRICE_UNAVAILABLE"
+	RejectKillSwitch       = "KILL_SWITCH"
+)
+
+// Exposure is the position held in a symbol and the quantity still working in open orders
+type Exposure struct {
+	NetPosition decimal.Decimal `json:"net_position"`
+	OpenBuy     decimal.Decimal `json:"open_buy"`
+	OpenSell    decimal.Decimal `json:"open_sell"`
+}
+
+// Projected returns the position if every open order on the given side and a new
+// order of quantity on that side were filled
+func (e Exposure) Projected(side OrderSide, quantity decimal.Decimal) decimal.Decimal {
+	if side == SideBuy {
+		return e.NetPosition.Add(e.OpenBuy).Add(quantity)
+	}
+	return e.NetPosition.Sub(e.OpenSell).Sub(quantity)
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,28 +14,78 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
 )
 
-// BinanceClient defines the interface for Binance API interactions
-type BinanceClient interface {
-	ExecuteTrade(ctx context.Context, order *domain.Order) error
+const (
+	binanceTestnetBaseURL = "https://testnet.binance.vision"
+	binanceSpotBaseURL    = "https://api.binance.com"
+
+	// binanceCodeNoSuchOrder is the Binance error code for an unknown order
+	binanceCodeNoSuchOrder = -2013
+	// binanceCodeBadSymbol is the Binance error code for an unknown symbol
+	binanceCodeBadSymbol = -1121
+	// binanceCodeUnknown and binanceCodeTimeout are Binance error codes for requests
+	// whose execution status is unknown
+	binanceCodeUnknown = -1006
+	binanceCodeTimeout = -1007
+	// binanceMsgDuplicateOrder is the message of a rejection because the client
+	// order ID is already in use, meaning an earlier attempt did place the order
+	binanceMsgDuplicateOrder = "Duplicate order sent."
+)
+
+// APIError is an error response returned by the Binance API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("binance API error (Status %d): code=%d msg=%s", e.StatusCode, e.Code, e.Message)
+}
+
+func init() {
+	Register("binance-testnet", func(cfg *config.Config) (ExchangeClient, error) {
+		c := cfg.Binance.Testnet
+		client := NewBinanceClient(c.APIKey, c.APISecret, orDefault(c.BaseURL, binanceTestnetBaseURL))
+		return client.WithStreamURL(orDefault(c.StreamURL, binanceTestnetStreamURL)), nil
+	})
+	Register("binance-spot", func(cfg *config.Config) (ExchangeClient, error) {
+		c := cfg.Binance.Spot
+		if c.APIKey == "" || c.APISecret == "" {
+			return nil, fmt.Errorf("binance-spot venue requires binance.spot api_key and api_secret")
+		}
+		client := NewBinanceClient(c.APIKey, c.APISecret, orDefault(c.BaseURL, binanceSpotBaseURL))
+		return client.WithStreamURL(orDefault(c.StreamURL, binanceSpotStreamURL)), nil
+	})
 }
 
-// BinanceTestnetClient is a client for Binance Testnet
+// BinanceTestnetClient is a client for the Binance Spot REST API.
+// Despite its name it serves both testnet and production, depending on the base URL.
 type BinanceTestnetClient struct {
 	apiKey     string
 	apiSecret  string
 	baseURL    string
+	streamURL  string
 	httpClient *http.Client
 }
 
 // NewBinanceTestnetClient creates a new Binance Testnet client
 func NewBinanceTestnetClient(key, secret string) *BinanceTestnetClient {
+	return NewBinanceClient(key, secret, binanceTestnetBaseURL)
+}
+
+// NewBinanceClient creates a Binance client against the given base URL
+func NewBinanceClient(key, secret, baseURL string) *BinanceTestnetClient {
 	return &BinanceTestnetClient{
 		apiKey:     key,
 		apiSecret:  secret,
-		baseURL:    "https://testnet.binance.vision",
+		baseURL:    baseURL,
+		streamURL:  binanceTestnetStreamURL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 	}
 }
@@ -45,79 +97,268 @@ func (b *BinanceTestnetClient) signRequest(params url.Values) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// ExecuteTrade executes a trade on Binance Testnet
-func (b *BinanceTestnetClient) ExecuteTrade(ctx context.Context, order *domain.Order) error {
-	endpoint := "/api/v3/order"
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
-
-	params := url.Values{}
-	params.Add("symbol", order.Symbol)
-	params.Add("side", string(order.Side))
-	params.Add("type", string(order.Type))
-	params.Add("quantity", fmt.Sprintf("%.8f", order.Quantity))
-	if order.Type == domain.TypeLimit {
-		params.Add("price", fmt.Sprintf("%.2f", order.Price))
-		params.Add("timeInForce", "GTC")
-	}
-	params.Add("timestamp", timestamp)
+// doSigned sends a signed request to a USER_DATA/TRADE endpoint and returns the response body
+func (b *BinanceTestnetClient) doSigned(ctx context.Context, method, endpoint string, params url.Values) ([]byte, error) {
+	params.Add("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
 	params.Add("signature", b.signRequest(params))
+	return b.do(ctx, method, endpoint, params)
+}
 
+// do sends a request to the Binance API and returns the response body
+func (b *BinanceTestnetClient) do(ctx context.Context, method, endpoint string, params url.Values) ([]byte, error) {
 	fullURL := fmt.Sprintf("%s%s?%s", b.baseURL, endpoint, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Add("X-MBX-APIKEY", b.apiKey)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if method == http.MethodPost {
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	}
 
 	resp, err := b.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("binance API error (Status %d): %s", resp.StatusCode, string(body))
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Code == 0 {
+			return nil, fmt.Errorf("binance API error (Status %d): %s", resp.StatusCode, string(body))
+		}
+		return nil, apiErr
 	}
 
-	return nil
+	return body, nil
 }
 
-// GetAccountBalance retrieves the account balance from Testnet
-func (b *BinanceTestnetClient) GetAccountBalance(ctx context.Context) error {
-	endpoint := "/api/v3/account"
-	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+// ExecuteTrade executes a trade on Binance Testnet and returns the parsed execution state
+func (b *BinanceTestnetClient) ExecuteTrade(ctx context.Context, order *domain.Order) (*OrderResponse, error) {
+	params := url.Values{}
+	params.Add("symbol", order.Symbol)
+	params.Add("side", string(order.Side))
+	params.Add("type", string(order.Type))
+	params.Add("newClientOrderId", order.ID)
+	params.Add("quantity", order.Quantity.String())
+	if order.Type.HasLimitPrice() {
+		params.Add("price", order.Price.String())
+		// LIMIT_MAKER orders are post-only and take no time in force
+		if order.Type != domain.TypeLimitMaker {
+			params.Add("timeInForce", "GTC")
+		}
+	}
+	if order.StopPrice.IsPositive() {
+		params.Add("stopPrice", order.StopPrice.String())
+	}
+	if order.TrailingDelta > 0 {
+		params.Add("trailingDelta", strconv.FormatInt(order.TrailingDelta, 10))
+	}
+	params.Add("newOrderRespType", "FULL")
 
+	body, err := b.doSigned(ctx, http.MethodPost, "/api/v3/order", params)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && isRejection(apiErr) {
+			return nil, fmt.Errorf("%w: %w", ErrOrderRejected, err)
+		}
+		return nil, err
+	}
+
+	var orderResp OrderResponse
+	if err := json.Unmarshal(body, &orderResp); err != nil {
+		return nil, fmt.Errorf("failed to decode order response: %w", err)
+	}
+
+	return &orderResp, nil
+}
+
+// isRejection reports whether a new order error means Binance did not place the
+// order. 5XX responses, timeouts and duplicate client order IDs leave it unknown.
+func isRejection(apiErr *APIError) bool {
+	if apiErr.StatusCode >= http.StatusInternalServerError {
+		return false
+	}
+	switch {
+	case apiErr.Code == binanceCodeUnknown, apiErr.Code == binanceCodeTimeout:
+		return false
+	case apiErr.Message == binanceMsgDuplicateOrder:
+		return false
+	}
+	return true
+}
+
+// CancelOrder cancels a resting order on Binance Testnet by its client order ID
+func (b *BinanceTestnetClient) CancelOrder(ctx context.Context, order *domain.Order) error {
 	params := url.Values{}
-	params.Add("timestamp", timestamp)
-	params.Add("signature", b.signRequest(params))
+	params.Add("symbol", order.Symbol)
+	params.Add("origClientOrderId", order.ID)
 
-	fullURL := fmt.Sprintf("%s%s?%s", b.baseURL, endpoint, params.Encode())
+	_, err := b.doSigned(ctx, http.MethodDelete, "/api/v3/order", params)
+	return err
+}
+
+// QueryOrder retrieves the current state of an order by its client order ID, including its trades
+func (b *BinanceTestnetClient) QueryOrder(ctx context.Context, order *domain.Order) (*OrderResponse, error) {
+	params := url.Values{}
+	params.Add("symbol", order.Symbol)
+	params.Add("origClientOrderId", order.ID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	body, err := b.doSigned(ctx, http.MethodGet, "/api/v3/order", params)
 	if err != nil {
-		return err
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == binanceCodeNoSuchOrder {
+			return nil, fmt.Errorf("%s: %w", order.ID, ErrOrderNotFound)
+		}
+		return nil, err
 	}
 
-	req.Header.Add("X-MBX-APIKEY", b.apiKey)
+	var orderResp OrderResponse
+	if err := json.Unmarshal(body, &orderResp); err != nil {
+		return nil, fmt.Errorf("failed to decode order response: %w", err)
+	}
 
-	resp, err := b.httpClient.Do(req)
+	if orderResp.ExecutedQty.IsPositive() {
+		fills, err := b.getOrderTrades(ctx, orderResp.Symbol, orderResp.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		orderResp.Fills = fills
+	}
+
+	return &orderResp, nil
+}
+
+// getOrderTrades retrieves the trades executed for an exchange order ID
+func (b *BinanceTestnetClient) getOrderTrades(ctx context.Context, symbol string, orderID int64) ([]FillResponse, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+	params.Add("orderId", strconv.FormatInt(orderID, 10))
+
+	body, err := b.doSigned(ctx, http.MethodGet, "/api/v3/myTrades", params)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("error getting account (Status %d): %s", resp.StatusCode, string(body))
+	var trades []struct {
+		ID              int64           `json:"id"`
+		Price           decimal.Decimal `json:"price"`
+		Qty             decimal.Decimal `json:"qty"`
+		Commission      decimal.Decimal `json:"commission"`
+		CommissionAsset string          `json:"commissionAsset"`
+	}
+	if err := json.Unmarshal(body, &trades); err != nil {
+		return nil, fmt.Errorf("failed to decode trades response: %w", err)
+	}
+
+	fills := make([]FillResponse, 0, len(trades))
+	for _, t := range trades {
+		fills = append(fills, FillResponse{
+			Price:           t.Price,
+			Qty:             t.Qty,
+			Commission:      t.Commission,
+			CommissionAsset: t.CommissionAsset,
+			TradeID:         t.ID,
+		})
+	}
+	return fills, nil
+}
+
+// GetOpenOrders lists the open orders for a symbol, or for all symbols if symbol is empty
+func (b *BinanceTestnetClient) GetOpenOrders(ctx context.Context, symbol string) ([]*OrderResponse, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Add("symbol", symbol)
+	}
+
+	body, err := b.doSigned(ctx, http.MethodGet, "/api/v3/openOrders", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*OrderResponse
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode open orders response: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetBalances retrieves the account balances from Testnet
+func (b *BinanceTestnetClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	body, err := b.doSigned(ctx, http.MethodGet, "/api/v3/account", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting account: %w", err)
+	}
+
+	var account struct {
+		Balances []Balance `json:"balances"`
+	}
+	if err := json.Unmarshal(body, &account); err != nil {
+		return nil, fmt.Errorf("failed to decode account response: %w", err)
 	}
 
-	return nil
+	return account.Balances, nil
+}
+
+// GetSymbolInfo retrieves the exchange metadata of a symbol
+func (b *BinanceTestnetClient) GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+
+	body, err := b.do(ctx, http.MethodGet, "/api/v3/exchangeInfo", params)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == binanceCodeBadSymbol {
+			return nil, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+		}
+		return nil, err
+	}
+
+	var info struct {
+		Symbols []SymbolInfo `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange info response: %w", err)
+	}
+
+	if len(info.Symbols) == 0 {
+		return nil, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+	}
+
+	return &info.Symbols[0], nil
+}
+
+// GetLastPrice retrieves the latest trade price of a symbol from the ticker
+func (b *BinanceTestnetClient) GetLastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	params := url.Values{}
+	params.Add("symbol", symbol)
+
+	body, err := b.do(ctx, http.MethodGet, "/api/v3/ticker/price", params)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	var ticker struct {
+		Price decimal.Decimal `json:"price"`
+	}
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode ticker response: %w", err)
+	}
+	return ticker.Price, nil
+}
+
+// orDefault returns value, or fallback when value is empty
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
 }
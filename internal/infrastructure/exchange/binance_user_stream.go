@@ -0,0 +1,220 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	binanceTestnetStreamURL = "wss://stream.testnet.binance.vision/ws"
+	binanceSpotStreamURL    = "wss://stream.binance.com:9443/ws"
+
+	// listenKeyKeepalive is how often the listen key is extended; Binance expires it after 60 minutes
+	listenKeyKeepalive = 30 * time.Minute
+)
+
+// ErrListenKeyExpired is returned when Binance reports that the user-data stream expired
+var ErrListenKeyExpired = errors.New("binance user-data stream listen key expired")
+
+// ExecutionReport is a Binance user-data stream executionReport event. Every key
+// Binance sends has a field: encoding/json matches keys case-insensitively when no
+// field matches exactly, so an unlisted "O" or "I" would be decoded into "o" or "i".
+type ExecutionReport struct {
+	EventType               string          `json:"e"`
+	EventTime               int64           `json:"E"`
+	Symbol                  string          `json:"s"`
+	ClientOrderID           string          `json:"c"`
+	OrigClientOrderID       string          `json:"C"`
+	Side                    string          `json:"S"`
+	OrderType               string          `json:"o"`
+	TimeInForce             string          `json:"f"`
+	Quantity                decimal.Decimal `json:"q"`
+	Price                   decimal.Decimal `json:"p"`
+	StopPrice               decimal.Decimal `json:"P"`
+	IcebergQty              decimal.Decimal `json:"F"`
+	OrderListID             int64           `json:"g"`
+	ExecutionType           string          `json:"x"`
+	OrderStatus             string          `json:"X"`
+	RejectReason            string          `json:"r"`
+	OrderID                 int64           `json:"i"`
+	LastExecutedQty         decimal.Decimal `json:"l"`
+	CumulativeQty           decimal.Decimal `json:"z"`
+	LastExecutedPrice       decimal.Decimal `json:"L"`
+	Commission              decimal.Decimal `json:"n"`
+	CommissionAsset         string          `json:"N"`
+	TransactionTime         int64           `json:"T"`
+	TradeID                 int64           `json:"t"`
+	PreventedMatchID        int64           `json:"v"`
+	Ignore                  int64           `json:"I"`
+	IsWorking               bool            `json:"w"`
+	IsMaker                 bool            `json:"m"`
+	IgnoreM                 bool            `json:"M"`
+	CreationTime            int64           `json:"O"`
+	CumulativeQuoteQty      decimal.Decimal `json:"Z"`
+	LastQuoteQty            decimal.Decimal `json:"Y"`
+	QuoteOrderQty           decimal.Decimal `json:"Q"`
+	WorkingTime             int64           `json:"W"`
+	SelfTradePreventionMode string          `json:"V"`
+}
+
+// OrderResponse converts the report into the venue-neutral order state.
+// Only TRADE reports carry a fill.
+func (r *ExecutionReport) OrderResponse() *OrderResponse {
+	clientOrderID := r.ClientOrderID
+	if r.ExecutionType == "CANCELED" && r.OrigClientOrderID != "" {
+		// For cancels "c" is the cancel request's ID and "C" the original order's
+		clientOrderID = r.OrigClientOrderID
+	}
+
+	resp := &OrderResponse{
+		Symbol:             r.Symbol,
+		OrderID:            r.OrderID,
+		ClientOrderID:      clientOrderID,
+		Status:             r.OrderStatus,
+		ExecutedQty:        r.CumulativeQty,
+		CumulativeQuoteQty: r.CumulativeQuoteQty,
+	}
+	if r.ExecutionType == "TRADE" {
+		resp.Fills = []FillResponse{{
+			Price:           r.LastExecutedPrice,
+			Qty:             r.LastExecutedQty,
+			Commission:      r.Commission,
+			CommissionAsset: r.CommissionAsset,
+			TradeID:         r.TradeID,
+		}}
+	}
+	return resp
+}
+
+// WithStreamURL overrides the user-data stream WebSocket base URL
+func (b *BinanceTestnetClient) WithStreamURL(streamURL string) *BinanceTestnetClient {
+	b.streamURL = streamURL
+	return b
+}
+
+// StreamExecutions runs one user-data stream session: it creates a listen key,
+// keeps it alive and passes every execution report to handler. It returns when
+// ctx is cancelled or the connection fails; callers reconnect by calling it again.
+func (b *BinanceTestnetClient) StreamExecutions(ctx context.Context, handler ExecutionHandler) error {
+	listenKey, err := b.createListenKey(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.closeListenKey(listenKey)
+
+	wsConfig, err := websocket.NewConfig(b.streamURL+"/"+listenKey, b.baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid stream URL: %w", err)
+	}
+	wsConfig.Dialer = &net.Dialer{Timeout: 10 * time.Second}
+
+	ws, err := websocket.DialConfig(wsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to user-data stream: %w", err)
+	}
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keepaliveErr := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(listenKeyKeepalive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sessionCtx.Done():
+				return
+			case <-ticker.C:
+				if err := b.keepaliveListenKey(sessionCtx, listenKey); err != nil {
+					keepaliveErr <- err
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	// Closing the socket unblocks Receive when the session ends
+	go func() {
+		<-sessionCtx.Done()
+		ws.Close()
+	}()
+
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			select {
+			case kaErr := <-keepaliveErr:
+				return fmt.Errorf("listen key keepalive failed: %w", kaErr)
+			default:
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("user-data stream read failed: %w", err)
+		}
+
+		// EventTime is decoded so the numeric "E" key is not matched case-insensitively to "e"
+		var envelope struct {
+			EventType string `json:"e"`
+			EventTime int64  `json:"E"`
+		}
+		if err := json.Unmarshal(msg, &envelope); err != nil {
+			return fmt.Errorf("failed to decode user-data event: %w", err)
+		}
+
+		switch envelope.EventType {
+		case "executionReport":
+			var report ExecutionReport
+			if err := json.Unmarshal(msg, &report); err != nil {
+				return fmt.Errorf("failed to decode execution report: %w", err)
+			}
+			handler(ctx, report.OrderResponse())
+		case "listenKeyExpired":
+			return ErrListenKeyExpired
+		}
+	}
+}
+
+// createListenKey opens a new user-data stream
+func (b *BinanceTestnetClient) createListenKey(ctx context.Context) (string, error) {
+	body, err := b.do(ctx, http.MethodPost, "/api/v3/userDataStream", url.Values{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create listen key: %w", err)
+	}
+
+	var resp struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode listen key response: %w", err)
+	}
+	return resp.ListenKey, nil
+}
+
+// keepaliveListenKey extends the validity of a listen key
+func (b *BinanceTestnetClient) keepaliveListenKey(ctx context.Context, listenKey string) error {
+	params := url.Values{}
+	params.Add("listenKey", listenKey)
+	_, err := b.do(ctx, http.MethodPut, "/api/v3/userDataStream", params)
+	return err
+}
+
+// closeListenKey closes a user-data stream; failures are ignored since the key expires anyway
+func (b *BinanceTestnetClient) closeListenKey(listenKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("listenKey", listenKey)
+	_, _ = b.do(ctx, http.MethodDelete, "/api/v3/userDataStream", params)
+}
@@ -0,0 +1,294 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+	"golang.org/x/net/websocket"
+)
+
+// userStreamStandIn is a local stand-in for the Binance listen key endpoints and
+// user-data WebSocket stream. Each connection is sent the messages of the next
+// session; every session but the last is then dropped by the server.
+type userStreamStandIn struct {
+	server   *httptest.Server
+	sessions [][]string
+
+	mu         sync.Mutex
+	listenKeys []string
+	connected  []string
+}
+
+func newUserStreamStandIn(t *testing.T, sessions ...[]string) *userStreamStandIn {
+	s := &userStreamStandIn{sessions: sessions}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/userDataStream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Write([]byte(`{}`))
+			return
+		}
+		s.mu.Lock()
+		key := fmt.Sprintf("listen-key-%d", len(s.listenKeys)+1)
+		s.listenKeys = append(s.listenKeys, key)
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"listenKey":%q}`, key)
+	})
+	mux.Handle("/ws/", websocket.Handler(s.serveSession))
+
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// client returns a Binance client pointed at the stand-in
+func (s *userStreamStandIn) client() *BinanceTestnetClient {
+	return NewBinanceClient("key", "secret", s.server.URL).
+		WithStreamURL("ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws")
+}
+
+func (s *userStreamStandIn) serveSession(ws *websocket.Conn) {
+	s.mu.Lock()
+	session := len(s.connected)
+	s.connected = append(s.connected, strings.TrimPrefix(ws.Request().URL.Path, "/ws/"))
+	s.mu.Unlock()
+	if session >= len(s.sessions) {
+		return
+	}
+
+	for _, msg := range s.sessions[session] {
+		if err := websocket.Message.Send(ws, msg); err != nil {
+			return
+		}
+	}
+	if session < len(s.sessions)-1 {
+		return
+	}
+	// Hold the last session open until the client goes away
+	var discard []byte
+	for websocket.Message.Receive(ws, &discard) == nil {
+	}
+}
+
+func (s *userStreamStandIn) stats() (listenKeys, connected []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.listenKeys...), append([]string(nil), s.connected...)
+}
+
+// binanceDocsExecutionReport is the executionReport sample from the Binance
+// user-data stream documentation, with every key Binance sends
+const binanceDocsExecutionReport = `{
+  "e": "executionReport",
+  "E": 1499405658658,
+  "s": "ETHBTC",
+  "c": "mUvoqJxFIILMdfAW5iGSOW",
+  "S": "BUY",
+  "o": "LIMIT",
+  "f": "GTC",
+  "q": "1.00000000",
+  "p": "0.10264410",
+  "P": "0.00000000",
+  "F": "0.00000000",
+  "g": -1,
+  "C": "",
+  "x": "NEW",
+  "X": "NEW",
+  "r": "NONE",
+  "i": 4293153,
+  "l": "0.00000000",
+  "z": "0.00000000",
+  "L": "0.00000000",
+  "n": "0",
+  "N": null,
+  "T": 1499405658657,
+  "t": -1,
+  "v": 3,
+  "I": 8641984,
+  "w": true,
+  "m": false,
+  "M": false,
+  "O": 1499405658657,
+  "Z": "0.00000000",
+  "Y": "0.00000000",
+  "Q": "0.00000000",
+  "W": 1499405658657,
+  "V": "NONE"
+}`
+
+// executionReport renders a TRADE executionReport for order-1 with every key Binance sends
+func executionReport(status string, tradeID int64, lastQty, lastPrice, cumQty, cumQuote string) string {
+	return fmt.Sprintf(`{"e":"executionReport","E":1700000000000,"s":"BTCUSDT","c":"order-1",`+
+		`"S":"BUY","o":"LIMIT","f":"GTC","q":"1.00000000","p":"64260.00000000","P":"0.00000000",`+
+		`"F":"0.00000000","g":-1,"C":"","x":"TRADE","X":%q,"r":"NONE","i":42,"l":%q,"z":%q,"L":%q,`+
+		`"n":"0","N":"BNB","T":1700000000001,"t":%d,"I":987654321,"w":false,"m":true,"M":true,`+
+		`"O":1699999999000,"Z":%q,"Y":"0.00000000","Q":"0.00000000","W":1699999999000,"V":"NONE"}`,
+		status, lastQty, cumQty, lastPrice, tradeID, cumQuote)
+}
+
+var (
+	partialFillReport = executionReport("PARTIALLY_FILLED", 7, "0.40000000", "64250.12000000", "0.40000000", "25700.04800000")
+	finalFillReport   = executionReport("FILLED", 8, "0.60000000", "64250.13000000", "1.00000000", "64250.12600000")
+)
+
+// applyReport moves an order the way the orchestrator applies execution reports
+func applyReport(order *domain.Order, resp *OrderResponse) {
+	order.ApplyExecution(resp.ExecutedQty, resp.CumulativeQuoteQty)
+	if newStatus := resp.OrderStatus(); newStatus != order.Status && order.CanTransitionTo(newStatus) {
+		order.Status = newStatus
+	}
+}
+
+func TestStreamExecutionsAppliesExecutionReports(t *testing.T) {
+	standIn := newUserStreamStandIn(t, []string{
+		`{"e":"outboundAccountPosition","E":1700000000000}`,
+		partialFillReport,
+		finalFillReport,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Account updates are skipped; the session ends once both reports arrived
+	var reports []*OrderResponse
+	err := standIn.client().StreamExecutions(ctx, func(_ context.Context, resp *OrderResponse) {
+		if reports = append(reports, resp); len(reports) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) || len(reports) != 2 {
+		t.Fatalf("expected 2 execution reports, got %d (stream error: %v)", len(reports), err)
+	}
+
+	order := domain.NewOrder("order-1", "BTCUSDT", domain.SideBuy, domain.TypeLimit,
+		decimal.RequireFromString("1"), decimal.RequireFromString("64260"))
+	order.Status = domain.StatusExecuting
+
+	applyReport(order, reports[0])
+	if order.Status != domain.StatusPartiallyFilled {
+		t.Fatalf("expected %s after the partial fill, got %s", domain.StatusPartiallyFilled, order.Status)
+	}
+	if want := decimal.RequireFromString("0.4"); !order.ExecutedQuantity.Equal(want) {
+		t.Errorf("expected executed quantity %s, got %s", want, order.ExecutedQuantity)
+	}
+	fills := reports[0].DomainFills(order.ID)
+	if len(fills) != 1 || fills[0].TradeID != 7 || !fills[0].Price.Equal(decimal.RequireFromString("64250.12")) {
+		t.Errorf("unexpected fills for the partial fill: %+v", fills)
+	}
+
+	applyReport(order, reports[1])
+	if order.Status != domain.StatusCompleted {
+		t.Fatalf("expected %s after the final fill, got %s", domain.StatusCompleted, order.Status)
+	}
+	if want := decimal.RequireFromString("1"); !order.ExecutedQuantity.Equal(want) {
+		t.Errorf("expected executed quantity %s, got %s", want, order.ExecutedQuantity)
+	}
+	if want := decimal.RequireFromString("64250.126"); !order.AvgFillPrice.Equal(want) {
+		t.Errorf("expected average fill price %s, got %s", want, order.AvgFillPrice)
+	}
+}
+
+func TestRunExecutionStreamReconnectsAfterDrop(t *testing.T) {
+	standIn := newUserStreamStandIn(t,
+		[]string{partialFillReport},
+		[]string{finalFillReport},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reports := make(chan *OrderResponse, 2)
+	var disconnects []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunExecutionStream(ctx, standIn.client(), func(_ context.Context, resp *OrderResponse) {
+			reports <- resp
+		}, 10*time.Millisecond, func(err error, _ time.Duration) {
+			disconnects = append(disconnects, err)
+		})
+	}()
+
+	var statuses []string
+	for len(statuses) < 2 {
+		select {
+		case resp := <-reports:
+			statuses = append(statuses, resp.Status)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for execution reports, got %v", statuses)
+		}
+	}
+	cancel()
+	<-done
+
+	if statuses[0] != "PARTIALLY_FILLED" || statuses[1] != "FILLED" {
+		t.Errorf("expected PARTIALLY_FILLED then FILLED, got %v", statuses)
+	}
+	if len(disconnects) != 1 || disconnects[0] == nil {
+		t.Errorf("expected one reported disconnect, got %v", disconnects)
+	}
+
+	// Each session opens a fresh listen key and connects with it
+	listenKeys, connected := standIn.stats()
+	if len(listenKeys) != 2 || len(connected) != 2 {
+		t.Fatalf("expected 2 listen keys and 2 connections, got %v and %v", listenKeys, connected)
+	}
+	for i := range listenKeys {
+		if connected[i] != listenKeys[i] {
+			t.Errorf("session %d connected with %q, want %q", i+1, connected[i], listenKeys[i])
+		}
+	}
+}
+
+func TestExecutionReportDecodesBinancePayload(t *testing.T) {
+	var report ExecutionReport
+	if err := json.Unmarshal([]byte(binanceDocsExecutionReport), &report); err != nil {
+		t.Fatalf("failed to decode the documented payload: %v", err)
+	}
+
+	// Uppercase keys must not land on their lowercase namesakes
+	if report.OrderType != "LIMIT" || report.OrderID != 4293153 || report.TradeID != -1 {
+		t.Errorf("expected LIMIT order 4293153 with trade -1, got %s order %d with trade %d",
+			report.OrderType, report.OrderID, report.TradeID)
+	}
+	if report.CreationTime != 1499405658657 || report.TransactionTime != 1499405658657 || report.Ignore != 8641984 {
+		t.Errorf("unexpected times or ignored ID: %+v", report)
+	}
+	if !report.Price.Equal(decimal.RequireFromString("0.1026441")) || report.TimeInForce != "GTC" || !report.IsWorking {
+		t.Errorf("unexpected order fields: %+v", report)
+	}
+
+	resp := report.OrderResponse()
+	if resp.ClientOrderID != "mUvoqJxFIILMdfAW5iGSOW" || resp.Status != "NEW" || len(resp.Fills) != 0 {
+		t.Errorf("unexpected order response: %+v", resp)
+	}
+}
+
+func TestStreamExecutionsDeliversBinancePayload(t *testing.T) {
+	standIn := newUserStreamStandIn(t, []string{binanceDocsExecutionReport})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var got *OrderResponse
+	err := standIn.client().StreamExecutions(ctx, func(_ context.Context, resp *OrderResponse) {
+		got = resp
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) || got == nil {
+		t.Fatalf("expected the documented report to be delivered, got %v (stream error: %v)", got, err)
+	}
+	if got.OrderID != 4293153 || got.Symbol != "ETHBTC" {
+		t.Errorf("unexpected order response: %+v", got)
+	}
+}
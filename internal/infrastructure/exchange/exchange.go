@@ -0,0 +1,207 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrOrderNotFound is returned when the venue has no record of an order
+	ErrOrderNotFound = errors.New("order not found on exchange")
+	// ErrSymbolNotFound is returned when the venue does not list a symbol
+	ErrSymbolNotFound = errors.New("symbol not found on exchange")
+	// ErrOrderRejected is returned when the venue definitely refused to place an order
+	ErrOrderRejected = errors.New("order rejected by exchange")
+)
+
+// ExchangeClient defines the operations the orchestrator needs from a trading venue
+type ExchangeClient interface {
+	// ExecuteTrade places a new order on the venue. Errors wrapping ErrOrderRejected
+	// mean the order was not placed; after any other error its placement is unknown.
+	ExecuteTrade(ctx context.Context, order *domain.Order) (*OrderResponse, error)
+	// CancelOrder cancels a resting order
+	CancelOrder(ctx context.Context, order *domain.Order) error
+	// QueryOrder retrieves the current execution state and fills of an order.
+	// It returns ErrOrderNotFound when the order never reached the venue.
+	QueryOrder(ctx context.Context, order *domain.Order) (*OrderResponse, error)
+	// GetOpenOrders lists the orders still resting on the venue for a symbol
+	GetOpenOrders(ctx context.Context, symbol string) ([]*OrderResponse, error)
+	// GetBalances retrieves the account balances
+	GetBalances(ctx context.Context) ([]Balance, error)
+	// GetSymbolInfo retrieves trading metadata and filters for a symbol.
+	// It returns ErrSymbolNotFound when the venue does not list the symbol.
+	GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error)
+	// GetLastPrice retrieves the last traded price of a symbol
+	GetLastPrice(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// ExecutionHandler receives order state pushed asynchronously by a venue
+type ExecutionHandler func(ctx context.Context, resp *OrderResponse)
+
+// ExecutionStreamer is implemented by venues that push execution reports
+type ExecutionStreamer interface {
+	StreamExecutions(ctx context.Context, handler ExecutionHandler) error
+}
+
+// maxStreamBackoff caps the wait between execution stream reconnects
+const maxStreamBackoff = time.Minute
+
+// RunExecutionStream runs execution stream sessions until ctx is cancelled,
+// reconnecting after each disconnect with exponential backoff starting at minBackoff.
+// onDisconnect is told why a session ended and how long until the next attempt.
+func RunExecutionStream(ctx context.Context, streamer ExecutionStreamer, handler ExecutionHandler,
+	minBackoff time.Duration, onDisconnect func(err error, backoff time.Duration)) {
+	backoff := minBackoff
+
+	for {
+		started := time.Now()
+		err := streamer.StreamExecutions(ctx, handler)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A session that stayed up for a while was healthy; start backing off afresh
+		if time.Since(started) > maxStreamBackoff {
+			backoff = minBackoff
+		}
+		onDisconnect(err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < maxStreamBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// BinanceClient is kept for existing callers; new code should use ExchangeClient
+type BinanceClient = ExchangeClient
+
+// OrderResponse is the venue-neutral execution state of an order.
+// Field tags follow the Binance order response, which adapters decode into directly;
+// amounts are decoded from Binance's decimal strings without going through float64.
+type OrderResponse struct {
+	Symbol             string          `json:"symbol"`
+	OrderID            int64           `json:"orderId"`
+	ClientOrderID      string          `json:"clientOrderId"`
+	Status             string          `json:"status"`
+	ExecutedQty        decimal.Decimal `json:"executedQty"`
+	CumulativeQuoteQty decimal.Decimal `json:"cummulativeQuoteQty"`
+	Fills              []FillResponse  `json:"fills"`
+}
+
+// FillResponse is a single trade execution reported for an order
+type FillResponse struct {
+	Price           decimal.Decimal `json:"price"`
+	Qty             decimal.Decimal `json:"qty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+	TradeID         int64           `json:"tradeId"`
+}
+
+// Balance holds the free and locked amount of an asset
+type Balance struct {
+	Asset  string          `json:"asset"`
+	Free   decimal.Decimal `json:"free"`
+	Locked decimal.Decimal `json:"locked"`
+}
+
+// SymbolInfo holds trading metadata for a symbol
+type SymbolInfo struct {
+	Symbol            string         `json:"symbol"`
+	Status            string         `json:"status"`
+	BaseAsset         string         `json:"baseAsset"`
+	QuoteAsset        string         `json:"quoteAsset"`
+	OrderTypes        []string       `json:"orderTypes"`
+	AllowTrailingStop bool           `json:"allowTrailingStop"`
+	Filters           []SymbolFilter `json:"filters"`
+}
+
+// Symbol filter types the order manager validates orders against
+const (
+	FilterPrice         = "PRICE_FILTER"
+	FilterLotSize       = "LOT_SIZE"
+	FilterMarketLotSize = "MARKET_LOT_SIZE"
+	FilterMinNotional   = "MIN_NOTIONAL"
+	FilterNotional      = "NOTIONAL"
+)
+
+// SymbolFilter is a trading rule of a symbol. Which fields are set depends on
+// FilterType; a zero value disables that part of the rule.
+type SymbolFilter struct {
+	FilterType       string          `json:"filterType"`
+	MinPrice         decimal.Decimal `json:"minPrice"`
+	MaxPrice         decimal.Decimal `json:"maxPrice"`
+	TickSize         decimal.Decimal `json:"tickSize"`
+	MinQty           decimal.Decimal `json:"minQty"`
+	MaxQty           decimal.Decimal `json:"maxQty"`
+	StepSize         decimal.Decimal `json:"stepSize"`
+	MinNotional      decimal.Decimal `json:"minNotional"`
+	MaxNotional      decimal.Decimal `json:"maxNotional"`
+	ApplyToMarket    bool            `json:"applyToMarket,omitempty"`
+	ApplyMinToMarket bool            `json:"applyMinToMarket,omitempty"`
+	ApplyMaxToMarket bool            `json:"applyMaxToMarket,omitempty"`
+}
+
+// Filter returns the filter of the given type, if the symbol has one
+func (s *SymbolInfo) Filter(filterType string) (SymbolFilter, bool) {
+	for _, f := range s.Filters {
+		if f.FilterType == filterType {
+			return f, true
+		}
+	}
+	return SymbolFilter{}, false
+}
+
+// AllowsOrderType reports whether the symbol accepts orders of the given type.
+// A venue that lists no order types accepts all of them.
+func (s *SymbolInfo) AllowsOrderType(orderType domain.OrderType) bool {
+	if len(s.OrderTypes) == 0 {
+		return true
+	}
+	for _, t := range s.OrderTypes {
+		if t == string(orderType) {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainFills converts the reported fills into domain fills for the given order
+func (r *OrderResponse) DomainFills(orderID string) []*domain.Fill {
+	fills := make([]*domain.Fill, 0, len(r.Fills))
+	for _, f := range r.Fills {
+		fills = append(fills, &domain.Fill{
+			OrderID:         orderID,
+			TradeID:         f.TradeID,
+			Price:           f.Price,
+			Quantity:        f.Qty,
+			Commission:      f.Commission,
+			CommissionAsset: f.CommissionAsset,
+		})
+	}
+	return fills
+}
+
+// OrderStatus maps the venue order status to the domain order status
+func (r *OrderResponse) OrderStatus() domain.OrderStatus {
+	switch r.Status {
+	case "FILLED":
+		return domain.StatusCompleted
+	case "PARTIALLY_FILLED":
+		return domain.StatusPartiallyFilled
+	case "CANCELED", "EXPIRED", "EXPIRED_IN_MATCH":
+		return domain.StatusCancelled
+	case "REJECTED":
+		return domain.StatusFailed
+	default:
+		return domain.StatusExecuting
+	}
+}
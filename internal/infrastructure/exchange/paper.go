@@ -0,0 +1,544 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	Register("paper", func(cfg *config.Config) (ExchangeClient, error) {
+		return NewPaperExchange(&cfg.Exchange.Paper, NewStaticPriceFeed(cfg.Exchange.Paper.Prices)), nil
+	})
+}
+
+// quoteAssets lists the quote currencies used to split a symbol into base and quote
+var quoteAssets = []string{"USDT", "USDC", "FDUSD", "BUSD", "EUR", "BTC", "ETH", "BNB"}
+
+// PriceFeed supplies the reference price the paper exchange builds its book around
+type PriceFeed interface {
+	LastPrice(symbol string) (decimal.Decimal, error)
+}
+
+// StaticPriceFeed is a PriceFeed backed by prices set in memory
+type StaticPriceFeed struct {
+	mu     sync.RWMutex
+	prices map[string]decimal.Decimal
+}
+
+// NewStaticPriceFeed creates a price feed seeded with the given prices
+func NewStaticPriceFeed(prices map[string]float64) *StaticPriceFeed {
+	feed := &StaticPriceFeed{prices: make(map[string]decimal.Decimal, len(prices))}
+	for symbol, price := range prices {
+		feed.prices[symbol] = decimal.NewFromFloat(price)
+	}
+	return feed
+}
+
+// LastPrice returns the current price of a symbol
+func (f *StaticPriceFeed) LastPrice(symbol string) (decimal.Decimal, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	price, ok := f.prices[symbol]
+	if !ok || !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("no price for symbol %s", symbol)
+	}
+	return price, nil
+}
+
+// SetPrice moves the price of a symbol; resting orders are matched on the next exchange call
+func (f *StaticPriceFeed) SetPrice(symbol string, price decimal.Decimal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prices[symbol] = price
+}
+
+// paperLevel is a price level of simulated liquidity
+type paperLevel struct {
+	price decimal.Decimal
+	qty   decimal.Decimal
+}
+
+// paperBook holds the simulated liquidity and the resting orders of a symbol
+type paperBook struct {
+	refPrice decimal.Decimal
+	asks     []paperLevel
+	bids     []paperLevel
+	resting  []*paperOrder
+}
+
+// paperOrder is the exchange-side state of an order
+type paperOrder struct {
+	order    domain.Order
+	orderID  int64
+	status   string
+	executed decimal.Decimal
+	quoteQty decimal.Decimal
+	fills    []FillResponse
+}
+
+// PaperExchange is an in-process ExchangeClient that simulates a venue.
+// Each symbol has a synthetic order book of DepthLevels levels around the feed
+// price; orders walk the book level by level, producing partial fills, fees
+// and balance changes. LIMIT orders that do not fully fill rest in the book and
+// are matched again whenever the feed price changes. LIMIT_MAKER orders that
+// would match on arrival are rejected; stop and take-profit orders are not simulated.
+type PaperExchange struct {
+	mu          sync.Mutex
+	feed        PriceFeed
+	feeRate     decimal.Decimal
+	depthLevels int
+	levelQty    decimal.Decimal
+	levelSpread decimal.Decimal
+	balances    map[string]*Balance
+	books       map[string]*paperBook
+	orders      map[string]*paperOrder
+	nextOrderID int64
+	nextTradeID int64
+}
+
+// NewPaperExchange creates a paper exchange using the given settings and price feed
+func NewPaperExchange(cfg *config.PaperConfig, feed PriceFeed) *PaperExchange {
+	p := &PaperExchange{
+		feed:        feed,
+		feeRate:     decimal.NewFromFloat(cfg.FeeRate),
+		depthLevels: cfg.DepthLevels,
+		levelQty:    decimal.NewFromFloat(cfg.LevelQuantity),
+		levelSpread: decimal.NewFromFloat(cfg.LevelSpreadBps).Shift(-4),
+		balances:    make(map[string]*Balance),
+		books:       make(map[string]*paperBook),
+		orders:      make(map[string]*paperOrder),
+	}
+	if p.depthLevels <= 0 {
+		p.depthLevels = 5
+	}
+	if !p.levelQty.IsPositive() {
+		p.levelQty = decimal.NewFromInt(1)
+	}
+	for asset, amount := range cfg.Balances {
+		p.balances[asset] = &Balance{Asset: asset, Free: decimal.NewFromFloat(amount)}
+	}
+	return p
+}
+
+// ExecuteTrade places an order and matches it against the simulated book
+func (p *PaperExchange) ExecuteTrade(ctx context.Context, order *domain.Order) (*OrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.orders[order.ID]; exists {
+		return nil, fmt.Errorf("paper exchange: duplicate order sent: %s", order.ID)
+	}
+	if order.Type != domain.TypeMarket && order.Type != domain.TypeLimit && order.Type != domain.TypeLimitMaker {
+		return nil, fmt.Errorf("paper exchange: %w: unsupported order type %s", ErrOrderRejected, order.Type)
+	}
+	if order.Type.HasLimitPrice() && !order.Price.IsPositive() {
+		return nil, fmt.Errorf("paper exchange: %w: %s order requires a price", ErrOrderRejected, order.Type)
+	}
+
+	// Nothing is placed until the order is stored below, so every failure up to
+	// then is a rejection, except the duplicate check above
+	base, quote, err := splitSymbol(order.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOrderRejected, err)
+	}
+	book, err := p.syncBook(order.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOrderRejected, err)
+	}
+	if order.Type == domain.TypeLimitMaker && crosses(order, book) {
+		return nil, fmt.Errorf("paper exchange: %w: LIMIT_MAKER order %s would immediately match and take", ErrOrderRejected, order.ID)
+	}
+
+	if err := p.reserve(order, base, quote, book); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOrderRejected, err)
+	}
+
+	p.nextOrderID++
+	po := &paperOrder{order: *order, orderID: p.nextOrderID, status: "NEW"}
+	p.orders[order.ID] = po
+
+	p.match(po, book, base, quote)
+
+	switch {
+	case !po.remaining().IsPositive():
+		po.status = "FILLED"
+	case order.Type == domain.TypeMarket:
+		// Liquidity ran out before the market order completed
+		po.status = "EXPIRED"
+	default:
+		if po.executed.IsPositive() {
+			po.status = "PARTIALLY_FILLED"
+		}
+		book.resting = append(book.resting, po)
+	}
+
+	return po.response(), nil
+}
+
+// CancelOrder cancels a resting order and releases its reserved balance
+func (p *PaperExchange) CancelOrder(ctx context.Context, order *domain.Order) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	po, ok := p.orders[order.ID]
+	if !ok {
+		return fmt.Errorf("paper exchange: unknown order %s", order.ID)
+	}
+	if _, err := p.syncBook(po.order.Symbol); err != nil {
+		return err
+	}
+	if po.status != "NEW" && po.status != "PARTIALLY_FILLED" {
+		return fmt.Errorf("paper exchange: order %s is %s and cannot be cancelled", order.ID, po.status)
+	}
+
+	base, quote, _ := splitSymbol(po.order.Symbol)
+	p.release(po, base, quote)
+	p.removeResting(po)
+	po.status = "CANCELED"
+	return nil
+}
+
+// QueryOrder returns the current state of an order, including all of its fills
+func (p *PaperExchange) QueryOrder(ctx context.Context, order *domain.Order) (*OrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	po, ok := p.orders[order.ID]
+	if !ok {
+		return nil, fmt.Errorf("paper exchange: %s: %w", order.ID, ErrOrderNotFound)
+	}
+	if _, err := p.syncBook(po.order.Symbol); err != nil {
+		return nil, err
+	}
+	return po.response(), nil
+}
+
+// GetOpenOrders lists the resting orders of a symbol, or of all symbols if symbol is empty
+func (p *PaperExchange) GetOpenOrders(ctx context.Context, symbol string) ([]*OrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	symbols := make([]string, 0, len(p.books))
+	if symbol != "" {
+		symbols = append(symbols, symbol)
+	} else {
+		for s := range p.books {
+			symbols = append(symbols, s)
+		}
+		sort.Strings(symbols)
+	}
+
+	var open []*OrderResponse
+	for _, s := range symbols {
+		book, err := p.syncBook(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, po := range book.resting {
+			open = append(open, po.response())
+		}
+	}
+	return open, nil
+}
+
+// GetBalances returns the simulated account balances
+func (p *PaperExchange) GetBalances(ctx context.Context) ([]Balance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	balances := make([]Balance, 0, len(p.balances))
+	for _, b := range p.balances {
+		balances = append(balances, *b)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Asset < balances[j].Asset })
+	return balances, nil
+}
+
+// GetSymbolInfo returns metadata for any symbol the price feed knows about. Its
+// filters only enforce the 8 decimal places the paper exchange rounds to.
+func (p *PaperExchange) GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	base, quote, err := splitSymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSymbolNotFound, err)
+	}
+	if _, err := p.feed.LastPrice(symbol); err != nil {
+		return nil, fmt.Errorf("paper exchange: %w: %w", ErrSymbolNotFound, err)
+	}
+	return &SymbolInfo{
+		Symbol:     symbol,
+		Status:     "TRADING",
+		BaseAsset:  base,
+		QuoteAsset: quote,
+		OrderTypes: []string{string(domain.TypeMarket), string(domain.TypeLimit), string(domain.TypeLimitMaker)},
+		Filters: []SymbolFilter{
+			{FilterType: FilterPrice, TickSize: decimal.New(1, -domain.AmountScale)},
+			{FilterType: FilterLotSize, StepSize: decimal.New(1, -domain.AmountScale)},
+		},
+	}, nil
+}
+
+// GetLastPrice returns the current feed price of a symbol
+func (p *PaperExchange) GetLastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	price, err := p.feed.LastPrice(symbol)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("paper exchange: %w", err)
+	}
+	return price, nil
+}
+
+// syncBook returns the book of a symbol, rebuilding its liquidity and matching
+// resting orders when the feed price has moved. Callers must hold p.mu.
+func (p *PaperExchange) syncBook(symbol string) (*paperBook, error) {
+	price, err := p.feed.LastPrice(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("paper exchange: %w", err)
+	}
+
+	book, ok := p.books[symbol]
+	if !ok {
+		book = &paperBook{}
+		p.books[symbol] = book
+	}
+	if book.refPrice.Equal(price) {
+		return book, nil
+	}
+
+	one := decimal.NewFromInt(1)
+	book.refPrice = price
+	book.asks = make([]paperLevel, p.depthLevels)
+	book.bids = make([]paperLevel, p.depthLevels)
+	for i := 0; i < p.depthLevels; i++ {
+		offset := p.levelSpread.Mul(decimal.NewFromInt(int64(i + 1)))
+		book.asks[i] = paperLevel{price: roundQty(price.Mul(one.Add(offset))), qty: p.levelQty}
+		book.bids[i] = paperLevel{price: roundQty(price.Mul(one.Sub(offset))), qty: p.levelQty}
+	}
+
+	base, quote, _ := splitSymbol(symbol)
+	resting := book.resting[:0]
+	for _, po := range book.resting {
+		p.match(po, book, base, quote)
+		if !po.remaining().IsPositive() {
+			po.status = "FILLED"
+			continue
+		}
+		if po.executed.IsPositive() {
+			po.status = "PARTIALLY_FILLED"
+		}
+		resting = append(resting, po)
+	}
+	book.resting = resting
+
+	return book, nil
+}
+
+// reserve checks and locks the balance an order needs. MARKET orders are only
+// checked against the best price; their funds are debited as they fill.
+func (p *PaperExchange) reserve(order *domain.Order, base, quote string, book *paperBook) error {
+	if order.Side == domain.SideBuy {
+		price := order.Price
+		if order.Type == domain.TypeMarket {
+			price = book.asks[0].price
+		}
+		need := order.Quantity.Mul(price)
+		bal := p.balance(quote)
+		if bal.Free.LessThan(need) {
+			return fmt.Errorf("paper exchange: insufficient %s balance: need %s, have %s", quote, need, bal.Free)
+		}
+		if order.Type.HasLimitPrice() {
+			bal.Free = bal.Free.Sub(need)
+			bal.Locked = bal.Locked.Add(need)
+		}
+		return nil
+	}
+
+	bal := p.balance(base)
+	if bal.Free.LessThan(order.Quantity) {
+		return fmt.Errorf("paper exchange: insufficient %s balance: need %s, have %s", base, order.Quantity, bal.Free)
+	}
+	if order.Type.HasLimitPrice() {
+		bal.Free = bal.Free.Sub(order.Quantity)
+		bal.Locked = bal.Locked.Add(order.Quantity)
+	}
+	return nil
+}
+
+// release returns the still-reserved balance of a resting LIMIT order
+func (p *PaperExchange) release(po *paperOrder, base, quote string) {
+	if !po.order.Type.HasLimitPrice() {
+		return
+	}
+	if po.order.Side == domain.SideBuy {
+		amount := po.remaining().Mul(po.order.Price)
+		bal := p.balance(quote)
+		bal.Locked = bal.Locked.Sub(amount)
+		bal.Free = bal.Free.Add(amount)
+		return
+	}
+	bal := p.balance(base)
+	bal.Locked = bal.Locked.Sub(po.remaining())
+	bal.Free = bal.Free.Add(po.remaining())
+}
+
+// match walks the book against an order, consuming liquidity level by level
+func (p *PaperExchange) match(po *paperOrder, book *paperBook, base, quote string) {
+	levels := book.asks
+	if po.order.Side == domain.SideSell {
+		levels = book.bids
+	}
+
+	for i := range levels {
+		level := &levels[i]
+		if !po.remaining().IsPositive() {
+			return
+		}
+		if !level.qty.IsPositive() {
+			continue
+		}
+		if po.order.Type.HasLimitPrice() {
+			if po.order.Side == domain.SideBuy && level.price.GreaterThan(po.order.Price) {
+				return
+			}
+			if po.order.Side == domain.SideSell && level.price.LessThan(po.order.Price) {
+				return
+			}
+		}
+
+		qty := decimal.Min(po.remaining(), level.qty)
+		if po.order.Side == domain.SideBuy && po.order.Type == domain.TypeMarket {
+			// Market buys stop when the quote balance runs out
+			affordable := p.balance(quote).Free.Div(level.price).RoundFloor(domain.AmountScale)
+			qty = decimal.Min(qty, affordable)
+		}
+		if !qty.IsPositive() {
+			return
+		}
+
+		level.qty = level.qty.Sub(qty)
+		p.fill(po, level.price, qty, base, quote)
+	}
+}
+
+// fill books a single trade: it records the fill, charges the fee and moves balances
+func (p *PaperExchange) fill(po *paperOrder, price, qty decimal.Decimal, base, quote string) {
+	cost := roundQty(price.Mul(qty))
+	baseBal, quoteBal := p.balance(base), p.balance(quote)
+
+	var commission decimal.Decimal
+	var commissionAsset string
+	if po.order.Side == domain.SideBuy {
+		commission, commissionAsset = roundQty(qty.Mul(p.feeRate)), base
+		if po.order.Type.HasLimitPrice() {
+			// Release the reservation at the limit price; price improvement goes back to free
+			reserved := qty.Mul(po.order.Price)
+			quoteBal.Locked = quoteBal.Locked.Sub(reserved)
+			quoteBal.Free = quoteBal.Free.Add(reserved.Sub(cost))
+		} else {
+			quoteBal.Free = quoteBal.Free.Sub(cost)
+		}
+		baseBal.Free = baseBal.Free.Add(qty.Sub(commission))
+	} else {
+		commission, commissionAsset = roundQty(cost.Mul(p.feeRate)), quote
+		if po.order.Type.HasLimitPrice() {
+			baseBal.Locked = baseBal.Locked.Sub(qty)
+		} else {
+			baseBal.Free = baseBal.Free.Sub(qty)
+		}
+		quoteBal.Free = quoteBal.Free.Add(cost.Sub(commission))
+	}
+
+	p.nextTradeID++
+	po.executed = po.executed.Add(qty)
+	po.quoteQty = po.quoteQty.Add(cost)
+	po.fills = append(po.fills, FillResponse{
+		Price:           price,
+		Qty:             qty,
+		Commission:      commission,
+		CommissionAsset: commissionAsset,
+		TradeID:         p.nextTradeID,
+	})
+}
+
+// crosses reports whether a limit order would match liquidity in the book on arrival
+func crosses(order *domain.Order, book *paperBook) bool {
+	levels := book.asks
+	if order.Side == domain.SideSell {
+		levels = book.bids
+	}
+	for _, level := range levels {
+		if !level.qty.IsPositive() {
+			continue
+		}
+		if order.Side == domain.SideBuy {
+			return !level.price.GreaterThan(order.Price)
+		}
+		return !level.price.LessThan(order.Price)
+	}
+	return false
+}
+
+// removeResting drops an order from its symbol's resting list
+func (p *PaperExchange) removeResting(po *paperOrder) {
+	book, ok := p.books[po.order.Symbol]
+	if !ok {
+		return
+	}
+	for i, r := range book.resting {
+		if r == po {
+			book.resting = append(book.resting[:i], book.resting[i+1:]...)
+			return
+		}
+	}
+}
+
+// balance returns the balance of an asset, creating an empty one if needed
+func (p *PaperExchange) balance(asset string) *Balance {
+	bal, ok := p.balances[asset]
+	if !ok {
+		bal = &Balance{Asset: asset}
+		p.balances[asset] = bal
+	}
+	return bal
+}
+
+// remaining returns the quantity of the order not yet filled
+func (po *paperOrder) remaining() decimal.Decimal {
+	return po.order.Quantity.Sub(po.executed)
+}
+
+// response renders the order state as an OrderResponse
+func (po *paperOrder) response() *OrderResponse {
+	fills := make([]FillResponse, len(po.fills))
+	copy(fills, po.fills)
+	return &OrderResponse{
+		Symbol:             po.order.Symbol,
+		OrderID:            po.orderID,
+		ClientOrderID:      po.order.ID,
+		Status:             po.status,
+		ExecutedQty:        po.executed,
+		CumulativeQuoteQty: po.quoteQty,
+		Fills:              fills,
+	}
+}
+
+// splitSymbol splits a symbol such as BTCUSDT into its base and quote assets
+func splitSymbol(symbol string) (string, string, error) {
+	for _, quote := range quoteAssets {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return strings.TrimSuffix(symbol, quote), quote, nil
+		}
+	}
+	return "", "", fmt.Errorf("paper exchange: cannot determine quote asset of %s", symbol)
+}
+
+// roundQty rounds a price or quantity to Binance's 8 decimal places
+func roundQty(v decimal.Decimal) decimal.Decimal {
+	return v.Round(domain.AmountScale)
+}
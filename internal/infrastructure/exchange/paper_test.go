@@ -0,0 +1,377 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// newTestPaperExchange creates a paper exchange around BTCUSDT at 100 with three
+// levels of 1 BTC, 10 bps apart: asks at 100.1, 100.2 and 100.3, bids at 99.9,
+// 99.8 and 99.7. Fees are 10 bps.
+func newTestPaperExchange() (*PaperExchange, *StaticPriceFeed) {
+	feed := NewStaticPriceFeed(map[string]float64{"BTCUSDT": 100})
+	return NewPaperExchange(&config.PaperConfig{
+		FeeRate:        0.001,
+		DepthLevels:    3,
+		LevelQuantity:  1,
+		LevelSpreadBps: 10,
+		Balances:       map[string]float64{"USDT": 10000, "BTC": 5},
+	}, feed), feed
+}
+
+// testOrder creates a BTCUSDT order; an empty price leaves it zero
+func testOrder(id string, side domain.OrderSide, orderType domain.OrderType, qty, price string) *domain.Order {
+	p := decimal.Zero
+	if price != "" {
+		p = decimal.RequireFromString(price)
+	}
+	return domain.NewOrder(id, "BTCUSDT", side, orderType, decimal.RequireFromString(qty), p)
+}
+
+// balanceOf returns the free and locked balance of an asset
+func balanceOf(t *testing.T, p *PaperExchange, asset string) (decimal.Decimal, decimal.Decimal) {
+	t.Helper()
+	balances, err := p.GetBalances(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get balances: %v", err)
+	}
+	for _, b := range balances {
+		if b.Asset == asset {
+			return b.Free, b.Locked
+		}
+	}
+	return decimal.Zero, decimal.Zero
+}
+
+func assertDecimal(t *testing.T, name string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(decimal.RequireFromString(want)) {
+		t.Errorf("%s: expected %s, got %s", name, want, got)
+	}
+}
+
+func TestPaperExchangeFillsAcrossDepthLevels(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      *domain.Order
+		wantStatus string
+		wantPrices []string
+		wantQtys   []string
+		wantQuote  string
+	}{
+		{
+			name:       "market buy walks the asks",
+			order:      testOrder("o1", domain.SideBuy, domain.TypeMarket, "2.5", ""),
+			wantStatus: "FILLED",
+			wantPrices: []string{"100.1", "100.2", "100.3"},
+			wantQtys:   []string{"1", "1", "0.5"},
+			wantQuote:  "250.45",
+		},
+		{
+			name:       "market sell walks the bids",
+			order:      testOrder("o1", domain.SideSell, domain.TypeMarket, "1.5", ""),
+			wantStatus: "FILLED",
+			wantPrices: []string{"99.9", "99.8"},
+			wantQtys:   []string{"1", "0.5"},
+			wantQuote:  "149.8",
+		},
+		{
+			name:       "market buy larger than the book expires",
+			order:      testOrder("o1", domain.SideBuy, domain.TypeMarket, "4", ""),
+			wantStatus: "EXPIRED",
+			wantPrices: []string{"100.1", "100.2", "100.3"},
+			wantQtys:   []string{"1", "1", "1"},
+			wantQuote:  "300.6",
+		},
+		{
+			name:       "limit buy fills up to its price and rests",
+			order:      testOrder("o1", domain.SideBuy, domain.TypeLimit, "2.5", "100.2"),
+			wantStatus: "PARTIALLY_FILLED",
+			wantPrices: []string{"100.1", "100.2"},
+			wantQtys:   []string{"1", "1"},
+			wantQuote:  "200.3",
+		},
+		{
+			name:       "limit sell below the bids rests unfilled",
+			order:      testOrder("o1", domain.SideSell, domain.TypeLimit, "1", "100.5"),
+			wantStatus: "NEW",
+			wantQuote:  "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPaperExchange()
+			resp, err := p.ExecuteTrade(context.Background(), tt.order)
+			if err != nil {
+				t.Fatalf("failed to execute trade: %v", err)
+			}
+
+			if resp.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, resp.Status)
+			}
+			if len(resp.Fills) != len(tt.wantPrices) {
+				t.Fatalf("expected %d fills, got %+v", len(tt.wantPrices), resp.Fills)
+			}
+			for i, fill := range resp.Fills {
+				assertDecimal(t, "fill price", fill.Price, tt.wantPrices[i])
+				assertDecimal(t, "fill quantity", fill.Qty, tt.wantQtys[i])
+			}
+			assertDecimal(t, "cumulative quote quantity", resp.CumulativeQuoteQty, tt.wantQuote)
+
+			open, err := p.GetOpenOrders(context.Background(), "BTCUSDT")
+			if err != nil {
+				t.Fatalf("failed to list open orders: %v", err)
+			}
+			wantOpen := tt.wantStatus == "NEW" || tt.wantStatus == "PARTIALLY_FILLED"
+			if (len(open) == 1) != wantOpen {
+				t.Errorf("expected resting order: %v, got %d open orders", wantOpen, len(open))
+			}
+		})
+	}
+}
+
+func TestPaperExchangeDeductsFees(t *testing.T) {
+	tests := []struct {
+		name           string
+		order          *domain.Order
+		wantCommission string
+		wantAsset      string
+		wantUSDT       string
+		wantBTC        string
+	}{
+		{
+			// Buyers pay the fee in the base asset
+			name:           "buy",
+			order:          testOrder("o1", domain.SideBuy, domain.TypeMarket, "1", ""),
+			wantCommission: "0.001",
+			wantAsset:      "BTC",
+			wantUSDT:       "9899.9",
+			wantBTC:        "5.999",
+		},
+		{
+			// Sellers pay the fee in the quote asset
+			name:           "sell",
+			order:          testOrder("o1", domain.SideSell, domain.TypeMarket, "1", ""),
+			wantCommission: "0.0999",
+			wantAsset:      "USDT",
+			wantUSDT:       "10099.8001",
+			wantBTC:        "4",
+		},
+		{
+			// A limit buy pays the book price and gets the rest of its reservation back
+			name:           "limit buy with price improvement",
+			order:          testOrder("o1", domain.SideBuy, domain.TypeLimit, "1", "100.3"),
+			wantCommission: "0.001",
+			wantAsset:      "BTC",
+			wantUSDT:       "9899.9",
+			wantBTC:        "5.999",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPaperExchange()
+			resp, err := p.ExecuteTrade(context.Background(), tt.order)
+			if err != nil {
+				t.Fatalf("failed to execute trade: %v", err)
+			}
+			if len(resp.Fills) != 1 {
+				t.Fatalf("expected one fill, got %+v", resp.Fills)
+			}
+			assertDecimal(t, "commission", resp.Fills[0].Commission, tt.wantCommission)
+			if resp.Fills[0].CommissionAsset != tt.wantAsset {
+				t.Errorf("expected commission in %s, got %s", tt.wantAsset, resp.Fills[0].CommissionAsset)
+			}
+
+			usdtFree, usdtLocked := balanceOf(t, p, "USDT")
+			btcFree, btcLocked := balanceOf(t, p, "BTC")
+			assertDecimal(t, "free USDT", usdtFree, tt.wantUSDT)
+			assertDecimal(t, "free BTC", btcFree, tt.wantBTC)
+			assertDecimal(t, "locked USDT", usdtLocked, "0")
+			assertDecimal(t, "locked BTC", btcLocked, "0")
+		})
+	}
+}
+
+func TestPaperExchangeReservesAndReleasesBalances(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      *domain.Order
+		asset      string
+		wantFree   string
+		wantLocked string
+		restore    string
+	}{
+		{
+			name:       "limit buy locks quote at its price",
+			order:      testOrder("o1", domain.SideBuy, domain.TypeLimit, "2", "99"),
+			asset:      "USDT",
+			wantFree:   "9802",
+			wantLocked: "198",
+			restore:    "10000",
+		},
+		{
+			name:       "limit sell locks base",
+			order:      testOrder("o1", domain.SideSell, domain.TypeLimit, "1.5", "101"),
+			asset:      "BTC",
+			wantFree:   "3.5",
+			wantLocked: "1.5",
+			restore:    "5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPaperExchange()
+			ctx := context.Background()
+			if _, err := p.ExecuteTrade(ctx, tt.order); err != nil {
+				t.Fatalf("failed to execute trade: %v", err)
+			}
+
+			free, locked := balanceOf(t, p, tt.asset)
+			assertDecimal(t, "free after placement", free, tt.wantFree)
+			assertDecimal(t, "locked after placement", locked, tt.wantLocked)
+
+			if err := p.CancelOrder(ctx, tt.order); err != nil {
+				t.Fatalf("failed to cancel order: %v", err)
+			}
+			free, locked = balanceOf(t, p, tt.asset)
+			assertDecimal(t, "free after cancel", free, tt.restore)
+			assertDecimal(t, "locked after cancel", locked, "0")
+
+			resp, err := p.QueryOrder(ctx, tt.order)
+			if err != nil {
+				t.Fatalf("failed to query order: %v", err)
+			}
+			if resp.Status != "CANCELED" {
+				t.Errorf("expected CANCELED, got %s", resp.Status)
+			}
+			if err := p.CancelOrder(ctx, tt.order); err == nil {
+				t.Error("expected cancelling a cancelled order to fail")
+			}
+		})
+	}
+}
+
+func TestPaperExchangeReleasesRemainderOfPartialFillOnCancel(t *testing.T) {
+	p, _ := newTestPaperExchange()
+	ctx := context.Background()
+
+	// 1 BTC fills at 100.1 against a reservation at 100.15; 1.5 BTC stays reserved
+	order := testOrder("o1", domain.SideBuy, domain.TypeLimit, "2.5", "100.15")
+	if _, err := p.ExecuteTrade(ctx, order); err != nil {
+		t.Fatalf("failed to execute trade: %v", err)
+	}
+	free, locked := balanceOf(t, p, "USDT")
+	assertDecimal(t, "free USDT", free, "9749.675")
+	assertDecimal(t, "locked USDT", locked, "150.225")
+
+	if err := p.CancelOrder(ctx, order); err != nil {
+		t.Fatalf("failed to cancel order: %v", err)
+	}
+	free, locked = balanceOf(t, p, "USDT")
+	assertDecimal(t, "free USDT after cancel", free, "9899.9")
+	assertDecimal(t, "locked USDT after cancel", locked, "0")
+}
+
+func TestPaperExchangeRejectsOrders(t *testing.T) {
+	tests := []struct {
+		name  string
+		order *domain.Order
+	}{
+		{"LIMIT_MAKER buy at the best ask", testOrder("o1", domain.SideBuy, domain.TypeLimitMaker, "1", "100.1")},
+		{"LIMIT_MAKER buy through the book", testOrder("o1", domain.SideBuy, domain.TypeLimitMaker, "1", "105")},
+		{"LIMIT_MAKER sell at the best bid", testOrder("o1", domain.SideSell, domain.TypeLimitMaker, "1", "99.9")},
+		{"buy beyond the quote balance", testOrder("o1", domain.SideBuy, domain.TypeLimit, "200", "99")},
+		{"sell beyond the base balance", testOrder("o1", domain.SideSell, domain.TypeMarket, "6", "")},
+		{"unsupported order type", testOrder("o1", domain.SideBuy, domain.TypeStopLoss, "1", "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPaperExchange()
+			_, err := p.ExecuteTrade(context.Background(), tt.order)
+			if !errors.Is(err, ErrOrderRejected) {
+				t.Fatalf("expected ErrOrderRejected, got %v", err)
+			}
+
+			// Nothing is placed or reserved for a rejected order
+			if _, err := p.QueryOrder(context.Background(), tt.order); !errors.Is(err, ErrOrderNotFound) {
+				t.Errorf("expected the rejected order to be unknown, got %v", err)
+			}
+			if _, locked := balanceOf(t, p, "USDT"); !locked.IsZero() {
+				t.Errorf("expected no locked USDT, got %s", locked)
+			}
+		})
+	}
+}
+
+func TestPaperExchangeAcceptsNonCrossingLimitMaker(t *testing.T) {
+	p, _ := newTestPaperExchange()
+	resp, err := p.ExecuteTrade(context.Background(), testOrder("o1", domain.SideBuy, domain.TypeLimitMaker, "1", "100"))
+	if err != nil {
+		t.Fatalf("failed to execute trade: %v", err)
+	}
+	if resp.Status != "NEW" || len(resp.Fills) != 0 {
+		t.Errorf("expected a resting order without fills, got %+v", resp)
+	}
+}
+
+func TestPaperExchangeFillsRestingOrdersWhenPriceMoves(t *testing.T) {
+	p, feed := newTestPaperExchange()
+	ctx := context.Background()
+
+	order := testOrder("o1", domain.SideBuy, domain.TypeLimit, "1.5", "99")
+	resp, err := p.ExecuteTrade(ctx, order)
+	if err != nil {
+		t.Fatalf("failed to execute trade: %v", err)
+	}
+	if resp.Status != "NEW" {
+		t.Fatalf("expected the order to rest, got %s", resp.Status)
+	}
+
+	steps := []struct {
+		price        string
+		wantStatus   string
+		wantExecuted string
+		wantFills    int
+	}{
+		// Asks at 98.9989 and 99.0978: only the first level is within the limit
+		{price: "98.9", wantStatus: "PARTIALLY_FILLED", wantExecuted: "1", wantFills: 1},
+		// The price moving up again fills nothing more
+		{price: "100", wantStatus: "PARTIALLY_FILLED", wantExecuted: "1", wantFills: 1},
+		// Asks at 98.098 and up fill the remaining 0.5
+		{price: "98", wantStatus: "FILLED", wantExecuted: "1.5", wantFills: 2},
+	}
+	for _, step := range steps {
+		feed.SetPrice("BTCUSDT", decimal.RequireFromString(step.price))
+		resp, err := p.QueryOrder(ctx, order)
+		if err != nil {
+			t.Fatalf("price %s: failed to query order: %v", step.price, err)
+		}
+		if resp.Status != step.wantStatus || len(resp.Fills) != step.wantFills {
+			t.Errorf("price %s: expected %s with %d fills, got %s with %+v",
+				step.price, step.wantStatus, step.wantFills, resp.Status, resp.Fills)
+		}
+		assertDecimal(t, "executed quantity at "+step.price, resp.ExecutedQty, step.wantExecuted)
+	}
+
+	open, err := p.GetOpenOrders(ctx, "")
+	if err != nil {
+		t.Fatalf("failed to list open orders: %v", err)
+	}
+	if len(open) != 0 {
+		t.Errorf("expected no open orders once filled, got %d", len(open))
+	}
+
+	// Fills at 98.9989 and 98.098 are paid from a reservation at 99
+	free, locked := balanceOf(t, p, "USDT")
+	assertDecimal(t, "free USDT", free, "9851.9521")
+	assertDecimal(t, "locked USDT", locked, "0")
+}
@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+)
+
+// Factory builds an ExchangeClient from the application configuration
+type Factory func(cfg *config.Config) (ExchangeClient, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an exchange adapter available under the given venue name.
+// It panics if the name is already registered.
+func Register(venue string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[venue]; exists {
+		panic(fmt.Sprintf("exchange: venue %q registered twice", venue))
+	}
+	registry[venue] = factory
+}
+
+// New creates the exchange client for the venue selected in the configuration
+func New(cfg *config.Config) (ExchangeClient, error) {
+	venue := cfg.Exchange.VenueName()
+
+	registryMu.RLock()
+	factory, ok := registry[venue]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown exchange venue %q (available: %v)", venue, Venues())
+	}
+	return factory(cfg)
+}
+
+// Venues returns the names of all registered venues
+func Venues() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,16 +2,21 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ivan-salazar14/nexus-order-manager/internal/application"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/messaging"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/infrastructure/persistence"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +26,7 @@ type HTTPServer struct {
 	logger       *zap.Logger
 	orchestrator *application.TradingOrchestrator
 	repo         *persistence.PostgresRepository
+	kafkaPool    messaging.KafkaPoolInterface
 	cfg          *config.Config
 	addr         string
 }
@@ -31,6 +37,7 @@ func NewHTTPServer(
 	logger *zap.Logger,
 	orchestrator *application.TradingOrchestrator,
 	repo *persistence.PostgresRepository,
+	kafkaPool messaging.KafkaPoolInterface,
 ) *HTTPServer {
 	e := echo.New()
 	e.HideBanner = true
@@ -40,6 +47,7 @@ func NewHTTPServer(
 		logger:       logger,
 		orchestrator: orchestrator,
 		repo:         repo,
+		kafkaPool:    kafkaPool,
 		cfg:          cfg,
 		addr:         fmt.Sprintf(":%d", 8080),
 	}
@@ -72,7 +80,20 @@ func (s *HTTPServer) setupRoutes() {
 	// Order handlers
 	api.POST("/orders", s.createOrder)
 	api.GET("/orders/:id", s.getOrder)
+	api.GET("/orders/:id/fills", s.getOrderFills)
 	api.GET("/orders", s.listOrders)
+	api.DELETE("/orders/:id", s.cancelOrder)
+
+	// Admin handlers
+	admin := api.Group("/admin")
+	admin.GET("/dlq", s.listDLQMessages)
+	admin.POST("/dlq/:partition/:offset/replay", s.replayDLQMessage)
+	admin.GET("/outbox/quarantined", s.listQuarantinedEvents)
+	admin.POST("/outbox/:id/requeue", s.requeueOutboxEvent)
+	admin.GET("/risk/limits", s.getRiskLimits)
+	admin.PUT("/risk/limits", s.updateRiskLimits)
+	admin.GET("/kill-switch", s.getKillSwitch)
+	admin.PUT("/kill-switch", s.setKillSwitch)
 }
 
 // healthCheck handles health check requests
@@ -86,12 +107,14 @@ func (s *HTTPServer) healthCheck(c echo.Context) error {
 // createOrder handles order creation
 func (s *HTTPServer) createOrder(c echo.Context) error {
 	var req struct {
-		ID       string  `json:"id"`
-		Symbol   string  `json:"symbol"`
-		Side     string  `json:"side"`
-		Type     string  `json:"type"`
-		Quantity float64 `json:"quantity"`
-		Price    float64 `json:"price"`
+		ID            string          `json:"id"`
+		Symbol        string          `json:"symbol"`
+		Side          string          `json:"side"`
+		Type          string          `json:"type"`
+		Quantity      decimal.Decimal `json:"quantity"`
+		Price         decimal.Decimal `json:"price"`
+		StopPrice     decimal.Decimal `json:"stop_price"`
+		TrailingDelta int64           `json:"trailing_delta"`
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -108,21 +131,38 @@ func (s *HTTPServer) createOrder(c echo.Context) error {
 		req.Quantity,
 		req.Price,
 	)
-
-	if !order.IsValid() {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid order data",
-		})
+	order.StopPrice = req.StopPrice
+	order.TrailingDelta = req.TrailingDelta
+	if requestID := c.Response().Header().Get(echo.HeaderXRequestID); len(requestID) <= 64 {
+		order.CorrelationID = requestID
 	}
 
-	if err := s.orchestrator.SubmitOrder(c.Request().Context(), order); err != nil {
+	idempotencyKey := c.Request().Header.Get("Idempotency-Key")
+	stored, created, err := s.orchestrator.SubmitOrder(c.Request().Context(), order, idempotencyKey)
+	if err != nil {
+		if errors.Is(err, domain.ErrIdempotencyConflict) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Order ID or Idempotency-Key already used with a different request",
+			})
+		}
+		if errors.Is(err, domain.ErrInvalidOrder) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		s.logger.Error("Failed to submit order", zap.Error(err))
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to submit order",
 		})
 	}
 
-	return c.JSON(http.StatusAccepted, order)
+	if stored.Status == domain.StatusRejected {
+		return c.JSON(http.StatusUnprocessableEntity, stored)
+	}
+	if !created {
+		return c.JSON(http.StatusOK, stored)
+	}
+	return c.JSON(http.StatusAccepted, stored)
 }
 
 // getOrder handles order retrieval
@@ -137,6 +177,48 @@ func (s *HTTPServer) getOrder(c echo.Context) error {
 	return c.JSON(http.StatusOK, order)
 }
 
+// getOrderFills handles retrieval of the fills of an order
+func (s *HTTPServer) getOrderFills(c echo.Context) error {
+	orderID := c.Param("id")
+	if _, err := s.repo.GetOrder(c.Request().Context(), orderID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Order not found",
+		})
+	}
+
+	fills, err := s.repo.GetFillsByOrderID(c.Request().Context(), orderID)
+	if err != nil {
+		s.logger.Error("Failed to list order fills", zap.String("order_id", orderID), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list order fills",
+		})
+	}
+	return c.JSON(http.StatusOK, fills)
+}
+
+// cancelOrder handles order cancellation
+func (s *HTTPServer) cancelOrder(c echo.Context) error {
+	orderID := c.Param("id")
+	order, err := s.orchestrator.CancelOrder(c.Request().Context(), orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Order not found",
+			})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Order cannot be cancelled in its current status",
+			})
+		}
+		s.logger.Error("Failed to cancel order", zap.String("order_id", orderID), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to cancel order",
+		})
+	}
+	return c.JSON(http.StatusOK, order)
+}
+
 // listOrders handles order listing
 func (s *HTTPServer) listOrders(c echo.Context) error {
 	status := domain.OrderStatus(c.QueryParam("status"))
@@ -152,6 +234,161 @@ func (s *HTTPServer) listOrders(c echo.Context) error {
 	return c.JSON(http.StatusOK, orders)
 }
 
+// listDLQMessages handles listing of dead-lettered order messages
+func (s *HTTPServer) listDLQMessages(c echo.Context) error {
+	limit := 50
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = n
+	}
+
+	messages, err := s.kafkaPool.ListDLQMessages(c.Request().Context(), limit)
+	if err != nil {
+		s.logger.Error("Failed to list DLQ messages", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DLQ messages",
+		})
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// replayDLQMessage handles replaying a dead-lettered message onto the orders topic
+func (s *HTTPServer) replayDLQMessage(c echo.Context) error {
+	partition, err := strconv.Atoi(c.Param("partition"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid partition",
+		})
+	}
+	offset, err := strconv.ParseInt(c.Param("offset"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offset",
+		})
+	}
+
+	if err := s.kafkaPool.ReplayDLQMessage(c.Request().Context(), partition, offset); err != nil {
+		s.logger.Error("Failed to replay DLQ message",
+			zap.Int("partition", partition),
+			zap.Int64("offset", offset),
+			zap.Error(err),
+		)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to replay DLQ message",
+		})
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// listQuarantinedEvents handles listing of outbox events the relay gave up on
+func (s *HTTPServer) listQuarantinedEvents(c echo.Context) error {
+	events, err := s.repo.ListQuarantinedOutboxEvents(c.Request().Context(), 50)
+	if err != nil {
+		s.logger.Error("Failed to list quarantined events", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list quarantined events",
+		})
+	}
+	return c.JSON(http.StatusOK, events)
+}
+
+// requeueOutboxEvent handles releasing a quarantined outbox event back to the relay
+func (s *HTTPServer) requeueOutboxEvent(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid event ID",
+		})
+	}
+
+	if err := s.repo.RequeueOutboxEvent(c.Request().Context(), id); err != nil {
+		if errors.Is(err, domain.ErrOutboxEventNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Quarantined event not found",
+			})
+		}
+		s.logger.Error("Failed to requeue outbox event", zap.Uint64("event_id", id), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to requeue outbox event",
+		})
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// getRiskLimits handles retrieval of the current pre-trade risk limits
+func (s *HTTPServer) getRiskLimits(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.orchestrator.Risk().Limits())
+}
+
+// updateRiskLimits handles updating the pre-trade risk limits at runtime. The body
+// is merged into the current limits, so fields left out keep their values.
+func (s *HTTPServer) updateRiskLimits(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	limits, err := s.orchestrator.Risk().UpdateLimits(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	s.logger.Info("Updated risk limits", zap.Bool("enabled", limits.Enabled))
+	return c.JSON(http.StatusOK, limits)
+}
+
+// getKillSwitch handles retrieval of the kill switch state
+func (s *HTTPServer) getKillSwitch(c echo.Context) error {
+	state, err := s.orchestrator.KillSwitch(c.Request().Context())
+	if err != nil {
+		s.logger.Error("Failed to get kill switch", zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get kill switch",
+		})
+	}
+	return c.JSON(http.StatusOK, state)
+}
+
+// setKillSwitch handles engaging or releasing the kill switch
+func (s *HTTPServer) setKillSwitch(c echo.Context) error {
+	var req struct {
+		Engaged          bool   `json:"engaged"`
+		Reason           string `json:"reason"`
+		CancelOpenOrders bool   `json:"cancel_open_orders"`
+	}
+	if err := c.Bind(&req); err != nil || req.Reason == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body, reason is required",
+		})
+	}
+
+	ctx := c.Request().Context()
+	var state application.KillSwitchState
+	var err error
+	if req.Engaged {
+		state, err = s.orchestrator.EngageKillSwitch(ctx, req.Reason, "api", req.CancelOpenOrders)
+	} else {
+		state, err = s.orchestrator.ReleaseKillSwitch(ctx, req.Reason, "api")
+	}
+	if err != nil {
+		s.logger.Error("Failed to toggle kill switch", zap.Bool("engaged", req.Engaged), zap.Error(err))
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error": "Failed to record kill switch change",
+			"state": state,
+		})
+	}
+	return c.JSON(http.StatusOK, state)
+}
+
 // Start starts the HTTP server
 func (s *HTTPServer) Start() error {
 	s.logger.Info("Starting HTTP server", zap.String("addr", s.addr))
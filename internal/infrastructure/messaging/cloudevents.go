@@ -0,0 +1,323 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/segmentio/kafka-go"
+)
+
+// CloudEvents 1.0 Kafka protocol binding
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventTypePrefix is prepended to outbox event types to form the CloudEvents type
+	CloudEventTypePrefix = "com.nexustrader."
+
+	contentTypeCloudEvents = "application/cloudevents+json"
+
+	HeaderContentType   = "content-type"
+	HeaderSpecVersion   = "ce_specversion"
+	HeaderID            = "ce_id"
+	HeaderSource        = "ce_source"
+	HeaderType          = "ce_type"
+	HeaderTime          = "ce_time"
+	HeaderSubject       = "ce_subject"
+	HeaderDataSchema    = "ce_dataschema"
+	HeaderCorrelationID = "ce_correlationid"
+	HeaderCausationID   = "ce_causationid"
+
+	defaultCloudEventSource = "/nexus-order-manager"
+)
+
+// CloudEvent is a CloudEvents 1.0 envelope. CorrelationID and CausationID are
+// carried as the correlationid and causationid extension attributes. JSON data is
+// inlined in Data; binary data, such as Protobuf or Avro, goes in DataBase64.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	Subject         string          `json:"subject,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	CausationID     string          `json:"causationid,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// payload returns the event data in whichever form it was carried
+func (ce *CloudEvent) payload() []byte {
+	if len(ce.DataBase64) > 0 {
+		return ce.DataBase64
+	}
+	return ce.Data
+}
+
+// setPayload stores the event data in the form its content type calls for
+func (ce *CloudEvent) setPayload(data []byte) {
+	if ce.DataContentType == ContentTypeJSON {
+		ce.Data = json.RawMessage(data)
+		return
+	}
+	ce.DataBase64 = data
+}
+
+// CloudEventType returns the CloudEvents type of an outbox event type
+func CloudEventType(eventType string) string {
+	return CloudEventTypePrefix + eventType
+}
+
+// eventCodec turns published values into CloudEvents messages, serializing typed
+// event payloads against their registered schema
+type eventCodec struct {
+	cfg        config.CloudEventsConfig
+	serializer Serializer
+	registry   *SchemaRegistry
+}
+
+// newEventCodec creates the codec for a Kafka configuration and registers the
+// built-in event schemas, failing if any is incompatible with the registry
+func newEventCodec(cfg *config.KafkaConfig) (*eventCodec, error) {
+	serializer, err := NewSerializer(cfg.Serialization.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := NewSchemaRegistry(cfg.Serialization.RegistryDir)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := BuiltinSchemas()
+	if err != nil {
+		return nil, err
+	}
+	for _, schema := range schemas {
+		if err := registry.Register(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.CloudEvents.Source == "" {
+		cfg.CloudEvents.Source = defaultCloudEventSource
+	}
+	return &eventCodec{cfg: cfg.CloudEvents, serializer: serializer, registry: registry}, nil
+}
+
+// newCloudEvent wraps a published value in a CloudEvent. Outbox events keep their
+// ID, type and timestamps so consumers can deduplicate redeliveries. Order events
+// are serialized with their versioned schema; other values are sent as plain JSON.
+func (c *eventCodec) newCloudEvent(key string, value interface{}) (*CloudEvent, error) {
+	ce := &CloudEvent{
+		SpecVersion: CloudEventsSpecVersion,
+		ID:          newEventID(),
+		Source:      c.cfg.Source,
+		Time:        time.Now().UTC(),
+		Subject:     key,
+	}
+
+	var eventType string
+	var version int
+	var payload []byte
+	switch v := value.(type) {
+	case *domain.OutboxEvent:
+		eventType, version, payload = v.EventType, v.SchemaVersion, []byte(v.Payload)
+		ce.ID = strconv.FormatUint(v.ID, 10)
+		ce.Time = v.CreatedAt
+		ce.Subject = v.AggregateID
+		ce.CorrelationID = v.CorrelationID
+		ce.CausationID = v.CausationID
+	case *domain.Order:
+		data, err := json.Marshal(domain.NewOrderEventV2(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		eventType, version, payload = domain.EventOrderSubmitted, domain.EventSchemaVersion, data
+		ce.Subject = v.ID
+		ce.CorrelationID = v.CorrelationID
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		ce.Type = CloudEventType("Event")
+		ce.DataContentType = ContentTypeJSON
+		ce.setPayload(data)
+		return ce, nil
+	}
+
+	ce.Type = CloudEventType(eventType)
+	if version == 0 {
+		// Events written before payloads were versioned match version 1
+		version = 1
+	}
+	schema, err := c.registry.Get(eventType, version)
+	if err != nil {
+		return nil, err
+	}
+	record, err := recordFromJSON(schema, payload)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.serializer.Serialize(schema, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", eventType, err)
+	}
+
+	ce.DataContentType = c.serializer.ContentType()
+	ce.DataSchema = schema.URI()
+	ce.setPayload(data)
+	return ce, nil
+}
+
+// encode builds the Kafka message for value in the configured CloudEvents mode
+func (c *eventCodec) encode(topic, key string, value interface{}) (kafka.Message, error) {
+	ce, err := c.newCloudEvent(key, value)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	msg := kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Time:  time.Now(),
+	}
+
+	if c.cfg.Mode == config.CloudEventsModeStructured {
+		data, err := json.Marshal(ce)
+		if err != nil {
+			return kafka.Message{}, fmt.Errorf("failed to marshal cloud event: %w", err)
+		}
+		msg.Value = data
+		msg.Headers = []kafka.Header{{Key: HeaderContentType, Value: []byte(contentTypeCloudEvents)}}
+	} else {
+		msg.Value = ce.payload()
+		msg.Headers = []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(ce.DataContentType)},
+			{Key: HeaderSpecVersion, Value: []byte(ce.SpecVersion)},
+			{Key: HeaderID, Value: []byte(ce.ID)},
+			{Key: HeaderSource, Value: []byte(ce.Source)},
+			{Key: HeaderType, Value: []byte(ce.Type)},
+			{Key: HeaderTime, Value: []byte(ce.Time.Format(time.RFC3339Nano))},
+			{Key: HeaderSubject, Value: []byte(ce.Subject)},
+		}
+		if ce.DataSchema != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderDataSchema, Value: []byte(ce.DataSchema)})
+		}
+	}
+
+	// Tracing IDs travel as headers in both modes so consumers can use them without decoding the body
+	if ce.CorrelationID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderCorrelationID, Value: []byte(ce.CorrelationID)})
+	}
+	if ce.CausationID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderCausationID, Value: []byte(ce.CausationID)})
+	}
+	return msg, nil
+}
+
+// decodeOrder extracts the order from an orders topic message. Messages are
+// CloudEvents in either mode; outbox events and bare orders published before the
+// CloudEvents envelope are still accepted. It returns nil for events other than
+// OrderSubmitted.
+func (c *eventCodec) decodeOrder(msg kafka.Message) (*domain.Order, error) {
+	ce, err := decodeCloudEvent(msg)
+	if err != nil {
+		return nil, err
+	}
+	if ce == nil {
+		return decodeLegacyOrder(msg.Value)
+	}
+	if ce.Type != CloudEventType(domain.EventOrderSubmitted) {
+		return nil, nil
+	}
+
+	schema, err := c.registry.Latest(domain.EventOrderSubmitted)
+	if ce.DataSchema != "" {
+		name, version, parseErr := parseSchemaURI(ce.DataSchema)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		schema, err = c.registry.Get(name, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serializer, err := serializerForContentType(ce.DataContentType)
+	if err != nil {
+		return nil, err
+	}
+	record, err := serializer.Deserialize(schema, ce.payload())
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize %s: %w", schema.URI(), err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order record: %w", err)
+	}
+	order, err := domain.OrderFromEvent(schema.Version, data)
+	if err != nil {
+		return nil, err
+	}
+	order.CorrelationID = ce.CorrelationID
+	return order, nil
+}
+
+// decodeCloudEvent reads a CloudEvent from a message in either mode. It returns
+// nil for messages that are not CloudEvents.
+func decodeCloudEvent(msg kafka.Message) (*CloudEvent, error) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	if strings.HasPrefix(headers[HeaderContentType], contentTypeCloudEvents) {
+		var ce CloudEvent
+		if err := json.Unmarshal(msg.Value, &ce); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cloud event: %w", err)
+		}
+		return &ce, nil
+	}
+
+	if headers[HeaderSpecVersion] == "" {
+		return nil, nil
+	}
+
+	ce := &CloudEvent{
+		SpecVersion:     headers[HeaderSpecVersion],
+		ID:              headers[HeaderID],
+		Source:          headers[HeaderSource],
+		Type:            headers[HeaderType],
+		Subject:         headers[HeaderSubject],
+		DataContentType: headers[HeaderContentType],
+		DataSchema:      headers[HeaderDataSchema],
+		CorrelationID:   headers[HeaderCorrelationID],
+		CausationID:     headers[HeaderCausationID],
+	}
+	ce.setPayload(msg.Value)
+	if t := headers[HeaderTime]; t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", HeaderTime, err)
+		}
+		ce.Time = parsed
+	}
+	return ce, nil
+}
+
+// newEventID returns a random CloudEvent ID for values that have no ID of their own
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
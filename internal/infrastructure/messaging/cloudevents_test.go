@@ -0,0 +1,102 @@
+package messaging
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderSubmittedRoundTripKeepsDecimals(t *testing.T) {
+	order := domain.NewOrder("order-1", "ETHBTC", domain.SideSell, domain.TypeStopLossLimit,
+		decimal.RequireFromString("1234567890.12345678"), decimal.RequireFromString("0.05234567"))
+	order.StopPrice = decimal.RequireFromString("0.05234568")
+	order.TrailingDelta = 200
+	order.ExecutedQuantity = decimal.RequireFromString("0.00000001")
+	order.CumulativeQuoteQty = decimal.RequireFromString("0.00000000052345")
+	order.AvgFillPrice = decimal.RequireFromString("0.05234567")
+	order.CreatedAt = time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	order.UpdatedAt = order.CreatedAt
+
+	payload, err := json.Marshal(domain.NewOrderEventV2(order))
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	event := &domain.OutboxEvent{
+		ID:            7,
+		AggregateID:   order.ID,
+		EventType:     domain.EventOrderSubmitted,
+		Payload:       string(payload),
+		SchemaVersion: domain.EventSchemaVersion,
+		CreatedAt:     order.CreatedAt,
+	}
+
+	for _, format := range []string{FormatJSON, FormatProtobuf, FormatAvro} {
+		for _, mode := range []string{config.CloudEventsModeBinary, config.CloudEventsModeStructured} {
+			cfg := &config.KafkaConfig{}
+			cfg.Serialization.Format = format
+			cfg.CloudEvents.Mode = mode
+			codec, err := newEventCodec(cfg)
+			if err != nil {
+				t.Fatalf("%s/%s: failed to create codec: %v", format, mode, err)
+			}
+
+			msg, err := codec.encode("orders", order.ID, event)
+			if err != nil {
+				t.Fatalf("%s/%s: failed to encode event: %v", format, mode, err)
+			}
+			decoded, err := codec.decodeOrder(msg)
+			if err != nil {
+				t.Fatalf("%s/%s: failed to decode order: %v", format, mode, err)
+			}
+
+			amounts := map[string][2]decimal.Decimal{
+				"quantity":                  {order.Quantity, decoded.Quantity},
+				"price":                     {order.Price, decoded.Price},
+				"executed quantity":         {order.ExecutedQuantity, decoded.ExecutedQuantity},
+				"cumulative quote quantity": {order.CumulativeQuoteQty, decoded.CumulativeQuoteQty},
+				"average fill price":        {order.AvgFillPrice, decoded.AvgFillPrice},
+				"stop price":                {order.StopPrice, decoded.StopPrice},
+			}
+			for name, a := range amounts {
+				if !a[1].Equal(a[0]) {
+					t.Errorf("%s/%s: %s: expected %s, got %s", format, mode, name, a[0], a[1])
+				}
+			}
+			if decoded.TrailingDelta != order.TrailingDelta {
+				t.Errorf("%s/%s: expected trailing delta %d, got %d", format, mode, order.TrailingDelta, decoded.TrailingDelta)
+			}
+		}
+	}
+}
+
+func TestOrderSubmittedV1StillDecodes(t *testing.T) {
+	cfg := &config.KafkaConfig{}
+	cfg.Serialization.Format = FormatProtobuf
+	codec, err := newEventCodec(cfg)
+	if err != nil {
+		t.Fatalf("failed to create codec: %v", err)
+	}
+
+	msg, err := codec.encode("orders", "order-1", &domain.OutboxEvent{
+		ID:            1,
+		AggregateID:   "order-1",
+		EventType:     domain.EventOrderSubmitted,
+		Payload:       `{"id":"order-1","symbol":"BTCUSDT","side":"BUY","type":"LIMIT","quantity":0.5,"price":64250.5,"status":"PENDING","executed_quantity":0,"cumulative_quote_quantity":0,"avg_fill_price":0,"created_at":"2026-10-16T09:30:00Z","updated_at":"2026-10-16T09:30:00Z"}`,
+		SchemaVersion: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+	order, err := codec.decodeOrder(msg)
+	if err != nil {
+		t.Fatalf("failed to decode order: %v", err)
+	}
+	if order.ID != "order-1" || !order.Quantity.Equal(decimal.RequireFromString("0.5")) ||
+		!order.Price.Equal(decimal.RequireFromString("64250.5")) {
+		t.Errorf("unexpected order decoded from a version 1 event: %+v", order)
+	}
+}
@@ -0,0 +1,252 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// Headers attached to dead-lettered messages
+const (
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+// DLQMessage is a dead-lettered message together with its failure metadata.
+// Value holds the raw bytes, rendered as base64 since messages often land here
+// because they are not valid JSON; ValueJSON repeats them when they are.
+type DLQMessage struct {
+	Partition         int             `json:"partition"`
+	Offset            int64           `json:"offset"`
+	Key               string          `json:"key"`
+	Value             []byte          `json:"value"`
+	ValueJSON         json.RawMessage `json:"value_json,omitempty"`
+	Headers           []kafka.Header  `json:"-"`
+	Error             string          `json:"error"`
+	Attempts          int             `json:"attempts"`
+	OriginalTopic     string          `json:"original_topic"`
+	OriginalPartition int             `json:"original_partition"`
+	OriginalOffset    int64           `json:"original_offset"`
+	FailedAt          time.Time       `json:"failed_at"`
+}
+
+// retryWithBackoff runs fn until it succeeds, the policy runs out of attempts or
+// ctx is cancelled. It returns the number of attempts made and the last error.
+func retryWithBackoff(ctx context.Context, policy config.RetryConfig, fn func() error) (int, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return attempt, nil
+		}
+		if attempt == maxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(policy.Backoff(attempt)):
+		}
+	}
+	return maxAttempts, err
+}
+
+// isDLQHeader reports whether a header is failure metadata added on dead-lettering
+func isDLQHeader(key string) bool {
+	switch key {
+	case HeaderError, HeaderAttempts, HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset, HeaderFailedAt:
+		return true
+	}
+	return false
+}
+
+// dlqHeaders builds the headers of a dead-lettered message: its original headers,
+// such as the CloudEvents attributes, followed by the failure metadata
+func dlqHeaders(msg kafka.Message, attempts int, cause error) []kafka.Header {
+	return append(append([]kafka.Header{}, msg.Headers...), []kafka.Header{
+		{Key: HeaderError, Value: []byte(cause.Error())},
+		{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		{Key: HeaderOriginalTopic, Value: []byte(msg.Topic)},
+		{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	}...)
+}
+
+// newDLQMessage decodes a message read from the dead-letter topic
+func newDLQMessage(msg kafka.Message) DLQMessage {
+	dlq := DLQMessage{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Value:     msg.Value,
+	}
+	if json.Valid(msg.Value) {
+		dlq.ValueJSON = msg.Value
+	}
+	for _, h := range msg.Headers {
+		if !isDLQHeader(h.Key) {
+			dlq.Headers = append(dlq.Headers, h)
+			continue
+		}
+		value := string(h.Value)
+		switch h.Key {
+		case HeaderError:
+			dlq.Error = value
+		case HeaderAttempts:
+			dlq.Attempts, _ = strconv.Atoi(value)
+		case HeaderOriginalTopic:
+			dlq.OriginalTopic = value
+		case HeaderOriginalPartition:
+			dlq.OriginalPartition, _ = strconv.Atoi(value)
+		case HeaderOriginalOffset:
+			dlq.OriginalOffset, _ = strconv.ParseInt(value, 10, 64)
+		case HeaderFailedAt:
+			dlq.FailedAt, _ = time.Parse(time.RFC3339Nano, value)
+		}
+	}
+	return dlq
+}
+
+// deadLetter publishes a message that exhausted its retries to the DLQ topic
+func (kp *KafkaPool) deadLetter(ctx context.Context, msg kafka.Message, attempts int, cause error) error {
+	dlqMsg := kafka.Message{
+		Topic:   kp.topics.OrdersDLQ,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: dlqHeaders(msg, attempts, cause),
+		Time:    time.Now(),
+	}
+
+	if err := kp.writeAndWait(ctx, dlqMsg); err != nil {
+		return fmt.Errorf("failed to publish to DLQ: %w", err)
+	}
+	return nil
+}
+
+// ListDLQMessages returns up to limit of the most recent messages of each DLQ partition
+func (kp *KafkaPool) ListDLQMessages(ctx context.Context, limit int) ([]DLQMessage, error) {
+	conn, err := kp.dialer.Dial("tcp", kp.producer.Addr.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(kp.topics.OrdersDLQ)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ partitions: %w", err)
+	}
+
+	var messages []DLQMessage
+	for _, p := range partitions {
+		msgs, err := kp.readDLQPartition(ctx, p.ID, limit)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msgs...)
+	}
+	return messages, nil
+}
+
+// readDLQPartition reads the last limit messages of a DLQ partition
+func (kp *KafkaPool) readDLQPartition(ctx context.Context, partition, limit int) ([]DLQMessage, error) {
+	conn, err := kp.dialer.DialLeader(ctx, "tcp", kp.producer.Addr.String(), kp.topics.OrdersDLQ, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DLQ partition %d: %w", partition, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ offsets: %w", err)
+	}
+	if first >= last {
+		return nil, nil
+	}
+
+	start := first
+	if limit > 0 && last-int64(limit) > first {
+		start = last - int64(limit)
+	}
+	if _, err := conn.Seek(start, kafka.SeekAbsolute); err != nil {
+		return nil, fmt.Errorf("failed to seek DLQ partition %d: %w", partition, err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return nil, err
+	}
+	batch := conn.ReadBatch(1, 10e6)
+	defer batch.Close()
+
+	var messages []DLQMessage
+	for {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			break
+		}
+		messages = append(messages, newDLQMessage(msg))
+		if msg.Offset >= last-1 {
+			break
+		}
+	}
+	return messages, nil
+}
+
+// ReplayDLQMessage republishes a dead-lettered message onto its original topic
+func (kp *KafkaPool) ReplayDLQMessage(ctx context.Context, partition int, offset int64) error {
+	conn, err := kp.dialer.DialLeader(ctx, "tcp", kp.producer.Addr.String(), kp.topics.OrdersDLQ, partition)
+	if err != nil {
+		return fmt.Errorf("failed to connect to DLQ partition %d: %w", partition, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return fmt.Errorf("failed to seek DLQ message: %w", err)
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return err
+	}
+	msg, err := conn.ReadMessage(10e6)
+	if err != nil {
+		return fmt.Errorf("failed to read DLQ message: %w", err)
+	}
+
+	dlq := newDLQMessage(msg)
+	topic := dlq.OriginalTopic
+	if topic == "" {
+		topic = kp.topics.Orders
+	}
+
+	replay := kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: dlq.Headers,
+		Time:    time.Now(),
+	}
+	if err := kp.writeAndWait(ctx, replay); err != nil {
+		return fmt.Errorf("failed to replay DLQ message: %w", err)
+	}
+
+	kp.logger.Info("Replayed DLQ message",
+		zap.Int("partition", partition),
+		zap.Int64("offset", offset),
+		zap.String("topic", topic),
+	)
+	return nil
+}
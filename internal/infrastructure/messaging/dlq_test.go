@@ -0,0 +1,45 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestDLQMessageMarshalsUndecodableValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []byte
+		wantJSON bool
+	}{
+		{"json", []byte(`{"id":"order-1","quantity":"0.001"}`), true},
+		{"protobuf", []byte{0x0a, 0x07, 'o', 'r', 'd', 'e', 'r', '-', '1', 0xff, 0x00}, false},
+		{"truncated json", []byte(`{"id":"order-1","quan`), false},
+		{"empty", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlq := newDLQMessage(kafka.Message{Partition: 0, Offset: 3, Key: []byte("order-1"), Value: tt.value})
+
+			// One bad entry must not break the whole listing
+			data, err := json.Marshal([]DLQMessage{dlq})
+			if err != nil {
+				t.Fatalf("failed to marshal DLQ message: %v", err)
+			}
+
+			var decoded []DLQMessage
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal DLQ listing: %v", err)
+			}
+			if !bytes.Equal(decoded[0].Value, tt.value) {
+				t.Errorf("value did not round-trip: got %x, want %x", decoded[0].Value, tt.value)
+			}
+			if got := decoded[0].ValueJSON != nil; got != tt.wantJSON {
+				t.Errorf("expected JSON view %v, got %s", tt.wantJSON, decoded[0].ValueJSON)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -17,50 +18,162 @@ import (
 type KafkaPool struct {
 	producer *kafka.Writer
 	reader   *kafka.Reader
+	dialer   *kafka.Dialer
 	logger   *zap.Logger
 	topics   config.KafkaTopicsConfig
+	retry    config.RetryConfig
+	parallel int
+	codec    *eventCodec
 	wg       sync.WaitGroup
 	ctx      context.Context
 	cancel   context.CancelFunc
 }
 
 // NewKafkaPool creates a new Kafka pool
-func NewKafkaPool(cfg *config.KafkaConfig, logger *zap.Logger) *KafkaPool {
+func NewKafkaPool(cfg *config.KafkaConfig, logger *zap.Logger) (*KafkaPool, error) {
+	codec, err := newEventCodec(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize event codec: %w", err)
+	}
+
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka security configuration: %w", err)
+	}
+
+	producer, err := newProducer(cfg, newTransport(dialer))
+	if err != nil {
+		return nil, fmt.Errorf("invalid producer configuration: %w", err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &KafkaPool{
-		producer: &kafka.Writer{
-			Addr:         kafka.TCP(cfg.Brokers...),
-			Balancer:     &kafka.LeastBytes{},
-			BatchTimeout: 10 * time.Millisecond,
-			Async:        false,
-		},
+	kp := &KafkaPool{
+		producer: producer,
 		reader: kafka.NewReader(kafka.ReaderConfig{
 			Brokers:  cfg.Brokers,
 			GroupID:  cfg.ConsumerGroup,
 			Topic:    cfg.Topics.Orders,
 			MinBytes: 10,
 			MaxBytes: 10e6,
+			Dialer:   dialer,
 		}),
-		logger: logger,
-		topics: cfg.Topics,
-		ctx:    ctx,
-		cancel: cancel,
+		dialer:   dialer,
+		logger:   logger,
+		topics:   cfg.Topics,
+		retry:    cfg.Retry,
+		parallel: cfg.Concurrency(),
+		codec:    codec,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if producer.Async {
+		producer.Completion = kp.onDelivery
 	}
+	return kp, nil
 }
 
-// PublishOrderEvent publishes an order event to Kafka
-func (kp *KafkaPool) PublishOrderEvent(ctx context.Context, event *domain.Order) error {
-	data, err := json.Marshal(event)
+// newProducer builds the Kafka writer from the producer settings. Messages are
+// partitioned by key so that the events of an order keep their order.
+func newProducer(cfg *config.KafkaConfig, transport *kafka.Transport) (*kafka.Writer, error) {
+	p := cfg.Producer
+
+	var acks kafka.RequiredAcks
+	switch p.Acks() {
+	case config.AcksAll:
+		acks = kafka.RequireAll
+	case config.AcksOne:
+		acks = kafka.RequireOne
+	case config.AcksNone:
+		acks = kafka.RequireNone
+	default:
+		return nil, fmt.Errorf("unknown required_acks %q", p.RequiredAcks)
+	}
+
+	var compression kafka.Compression
+	switch p.Compression {
+	case "", "none":
+	case "gzip":
+		compression = kafka.Gzip
+	case "snappy":
+		compression = kafka.Snappy
+	case "lz4":
+		compression = kafka.Lz4
+	case "zstd":
+		compression = kafka.Zstd
+	default:
+		return nil, fmt.Errorf("unknown compression %q", p.Compression)
+	}
+
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: acks,
+		Compression:  compression,
+		MaxAttempts:  p.MaxAttempts,
+		BatchSize:    p.BatchSize,
+		BatchBytes:   p.BatchBytes,
+		BatchTimeout: p.BatchTimeout(),
+		WriteTimeout: p.WriteTimeout(),
+		Async:        p.Async,
+		Transport:    transport,
+	}, nil
+}
+
+// onDelivery is the completion handler of an async producer. It reports the
+// outcome of each message to the callback it was written with, if any.
+func (kp *KafkaPool) onDelivery(messages []kafka.Message, err error) {
 	if err != nil {
-		return fmt.Errorf("failed to marshal order event: %w", err)
+		err = fmt.Errorf("failed to publish event: %w", err)
+	}
+	for _, msg := range messages {
+		if delivered, ok := msg.WriterData.(func(error)); ok {
+			delivered(err)
+			continue
+		}
+		if err != nil {
+			kp.logger.Error("Failed to deliver message",
+				zap.String("topic", msg.Topic),
+				zap.String("key", string(msg.Key)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// writeAndWait writes messages and waits until the broker acknowledged them, also
+// when the producer is async
+func (kp *KafkaPool) writeAndWait(ctx context.Context, msgs ...kafka.Message) error {
+	if !kp.producer.Async {
+		return kp.producer.WriteMessages(ctx, msgs...)
+	}
+
+	done := make(chan error, len(msgs))
+	for i := range msgs {
+		msgs[i].WriterData = func(err error) { done <- err }
+	}
+	if err := kp.producer.WriteMessages(ctx, msgs...); err != nil {
+		return err
 	}
 
-	msg := kafka.Message{
-		Topic: kp.topics.Orders,
-		Key:   []byte(event.ID),
-		Value: data,
-		Time:  time.Now(),
+	for range msgs {
+		select {
+		case err := <-done:
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// PublishOrderEvent publishes an order event to Kafka
+func (kp *KafkaPool) PublishOrderEvent(ctx context.Context, event *domain.Order) error {
+	msg, err := kp.codec.encode(kp.topics.Orders, event.ID, event)
+	if err != nil {
+		return fmt.Errorf("failed to encode order event: %w", err)
 	}
 
 	if err := kp.producer.WriteMessages(ctx, msg); err != nil {
@@ -77,16 +190,9 @@ func (kp *KafkaPool) PublishOrderEvent(ctx context.Context, event *domain.Order)
 
 // PublishGenericEvent publishes a generic event to Kafka
 func (kp *KafkaPool) PublishGenericEvent(ctx context.Context, topic string, key string, value interface{}) error {
-	data, err := json.Marshal(value)
+	msg, err := kp.codec.encode(topic, key, value)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
-
-	msg := kafka.Message{
-		Topic: topic,
-		Key:   []byte(key),
-		Value: data,
-		Time:  time.Now(),
+		return err
 	}
 
 	if err := kp.producer.WriteMessages(ctx, msg); err != nil {
@@ -96,8 +202,62 @@ func (kp *KafkaPool) PublishGenericEvent(ctx context.Context, topic string, key
 	return nil
 }
 
-// ConsumeOrderEvents starts consuming order events
+// PublishBatch publishes messages in a single write. When only some messages fail
+// the returned error is a BatchError telling which ones. With an async producer
+// only messages rejected before they were queued fail here; the outcome of the
+// others is reported to their Delivered callbacks.
+func (kp *KafkaPool) PublishBatch(ctx context.Context, messages []Message) error {
+	errs := make(BatchError, len(messages))
+	batch := make([]kafka.Message, 0, len(messages))
+	positions := make([]int, 0, len(messages))
+
+	for i, m := range messages {
+		msg, err := kp.codec.encode(m.Topic, m.Key, m.Value)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if kp.producer.Async && m.Delivered != nil {
+			msg.WriterData = m.Delivered
+		}
+		batch = append(batch, msg)
+		positions = append(positions, i)
+	}
+
+	if len(batch) > 0 {
+		if err := kp.producer.WriteMessages(ctx, batch...); err != nil {
+			var writeErrs kafka.WriteErrors
+			if !errors.As(err, &writeErrs) {
+				writeErrs = make(kafka.WriteErrors, len(batch))
+				for j := range writeErrs {
+					writeErrs[j] = err
+				}
+			}
+			for j, werr := range writeErrs {
+				if werr != nil {
+					errs[positions[j]] = fmt.Errorf("failed to publish event: %w", werr)
+				}
+			}
+		}
+	}
+
+	for i, m := range messages {
+		if m.Delivered != nil && (!kp.producer.Async || errs[i] != nil) {
+			m.Delivered(errs[i])
+		}
+	}
+	return errs.errOrNil()
+}
+
+// ConsumeOrderEvents starts consuming order events. Up to the configured consumer
+// concurrency of messages are handled at once, so one slow order does not hold up
+// the others. A failing message is retried with exponential backoff; once the retry
+// policy is exhausted it is moved to the DLQ topic. A message is committed only after
+// it and every earlier message of its partition were handled or dead-lettered.
 func (kp *KafkaPool) ConsumeOrderEvents(handler func(*domain.Order) error) {
+	slots := make(chan struct{}, kp.parallel)
+	commits := newCommitTracker()
+
 	kp.wg.Add(1)
 	go func() {
 		defer kp.wg.Done()
@@ -105,35 +265,143 @@ func (kp *KafkaPool) ConsumeOrderEvents(handler func(*domain.Order) error) {
 			select {
 			case <-kp.ctx.Done():
 				return
-			default:
-				msg, err := kp.reader.FetchMessage(kp.ctx)
-				if err != nil {
-					if kp.ctx.Err() != nil {
-						return
-					}
-					kp.logger.Error("Error fetching message", zap.Error(err))
-					continue
-				}
+			case slots <- struct{}{}:
+			}
 
-				var order domain.Order
-				if err := json.Unmarshal(msg.Value, &order); err != nil {
-					kp.logger.Error("Error unmarshaling message", zap.Error(err))
-					continue
+			msg, err := kp.reader.FetchMessage(kp.ctx)
+			if err != nil {
+				<-slots
+				if kp.ctx.Err() != nil {
+					return
 				}
+				kp.logger.Error("Error fetching message", zap.Error(err))
+				continue
+			}
 
-				if err := handler(&order); err != nil {
-					kp.logger.Error("Error handling order", zap.Error(err))
-					continue
+			tracked := commits.track(msg)
+			kp.wg.Add(1)
+			go func() {
+				defer kp.wg.Done()
+				defer func() { <-slots }()
+				if !kp.consumeMessage(msg, handler) {
+					return
 				}
-
-				if err := kp.reader.CommitMessages(kp.ctx, msg); err != nil {
+				err := commits.complete(tracked, func(last kafka.Message) error {
+					return kp.reader.CommitMessages(kp.ctx, last)
+				})
+				if err != nil {
 					kp.logger.Error("Error committing message", zap.Error(err))
 				}
-			}
+			}()
 		}
 	}()
 }
 
+// consumeMessage handles a message, dead-lettering it if it keeps failing. It
+// returns false if the pool shut down before the message was settled.
+func (kp *KafkaPool) consumeMessage(msg kafka.Message, handler func(*domain.Order) error) bool {
+	attempts, err := kp.handleMessage(msg, handler)
+	if err == nil {
+		return true
+	}
+	if kp.ctx.Err() != nil {
+		return false
+	}
+	kp.logger.Error("Order message failed, moving to DLQ",
+		zap.Int("partition", msg.Partition),
+		zap.Int64("offset", msg.Offset),
+		zap.Int("attempts", attempts),
+		zap.Error(err),
+	)
+	return kp.deadLetterWithRetry(msg, attempts, err)
+}
+
+// commitTracker orders the commits of messages handled concurrently. Kafka commits
+// an offset for everything before it in the partition, so a message may only be
+// committed once all earlier messages of its partition are done.
+type commitTracker struct {
+	mu      sync.Mutex
+	pending map[int][]*trackedMessage
+}
+
+// trackedMessage is a fetched message waiting to be committed
+type trackedMessage struct {
+	msg  kafka.Message
+	done bool
+}
+
+// newCommitTracker creates an empty commit tracker
+func newCommitTracker() *commitTracker {
+	return &commitTracker{pending: make(map[int][]*trackedMessage)}
+}
+
+// track registers a fetched message; messages must be tracked in fetch order
+func (t *commitTracker) track(msg kafka.Message) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := &trackedMessage{msg: msg}
+	t.pending[msg.Partition] = append(t.pending[msg.Partition], m)
+	return m
+}
+
+// complete marks a message done and commits the last message of the run of done
+// messages at the head of its partition, if any. Commits are made under the lock
+// so they never go backwards.
+func (t *commitTracker) complete(m *trackedMessage, commit func(last kafka.Message) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m.done = true
+	queue := t.pending[m.msg.Partition]
+	n := 0
+	for n < len(queue) && queue[n].done {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	last := queue[n-1].msg
+	t.pending[m.msg.Partition] = queue[n:]
+	return commit(last)
+}
+
+// handleMessage decodes a message and runs handler under the retry policy.
+// Undecodable messages are not retried.
+func (kp *KafkaPool) handleMessage(msg kafka.Message, handler func(*domain.Order) error) (int, error) {
+	order, err := kp.codec.decodeOrder(msg)
+	if err != nil {
+		return 1, err
+	}
+
+	// Only order submissions are executed; anything else is acknowledged as is
+	if order == nil {
+		return 0, nil
+	}
+
+	return retryWithBackoff(kp.ctx, kp.retry, func() error {
+		return handler(order)
+	})
+}
+
+// deadLetterWithRetry keeps trying to dead-letter a message so the partition does
+// not move past it. It returns false if the pool is shutting down.
+func (kp *KafkaPool) deadLetterWithRetry(msg kafka.Message, attempts int, cause error) bool {
+	for attempt := 1; ; attempt++ {
+		err := kp.deadLetter(kp.ctx, msg, attempts, cause)
+		if err == nil {
+			return true
+		}
+		kp.logger.Error("Error publishing to DLQ", zap.Error(err))
+
+		select {
+		case <-kp.ctx.Done():
+			return false
+		case <-time.After(kp.retry.Backoff(attempt)):
+		}
+	}
+}
+
 // Close closes the Kafka pool
 func (kp *KafkaPool) Close() error {
 	kp.cancel()
@@ -152,7 +420,7 @@ func (kp *KafkaPool) Close() error {
 
 // EnsureTopicsExist creates topics if they don't exist
 func (kp *KafkaPool) EnsureTopicsExist() error {
-	conn, err := kafka.Dial("tcp", kp.producer.Addr.String())
+	conn, err := kp.dialer.Dial("tcp", kp.producer.Addr.String())
 	if err != nil {
 		return fmt.Errorf("failed to connect to Kafka: %w", err)
 	}
@@ -163,7 +431,7 @@ func (kp *KafkaPool) EnsureTopicsExist() error {
 		return fmt.Errorf("failed to get controller: %w", err)
 	}
 
-	controllerConn, err := kafka.Dial("tcp", fmt.Sprintf("%s:%d", controller.Host, controller.Port))
+	controllerConn, err := kp.dialer.Dial("tcp", fmt.Sprintf("%s:%d", controller.Host, controller.Port))
 	if err != nil {
 		return fmt.Errorf("failed to connect to controller: %w", err)
 	}
@@ -180,6 +448,11 @@ func (kp *KafkaPool) EnsureTopicsExist() error {
 			NumPartitions:     3,
 			ReplicationFactor: 1,
 		},
+		{
+			Topic:             kp.topics.OrdersDLQ,
+			NumPartitions:     1,
+			ReplicationFactor: 1,
+		},
 	}
 
 	err = controllerConn.CreateTopics(topics...)
@@ -190,3 +463,27 @@ func (kp *KafkaPool) EnsureTopicsExist() error {
 
 	return nil
 }
+
+// decodeLegacyOrder extracts the order from a message published before the
+// CloudEvents envelope: an outbox event or a bare order. It returns nil for
+// events other than OrderSubmitted.
+func decodeLegacyOrder(value []byte) (*domain.Order, error) {
+	var event domain.OutboxEvent
+	if err := json.Unmarshal(value, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	payload := value
+	if event.EventType != "" {
+		if event.EventType != domain.EventOrderSubmitted {
+			return nil, nil
+		}
+		payload = []byte(event.Payload)
+	}
+
+	var order domain.Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order: %w", err)
+	}
+	return &order, nil
+}
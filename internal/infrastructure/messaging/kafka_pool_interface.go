@@ -2,6 +2,7 @@ package messaging
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
 )
@@ -10,7 +11,49 @@ import (
 type KafkaPoolInterface interface {
 	PublishOrderEvent(ctx context.Context, event *domain.Order) error
 	PublishGenericEvent(ctx context.Context, topic string, key string, value interface{}) error
+	PublishBatch(ctx context.Context, messages []Message) error
 	ConsumeOrderEvents(handler func(*domain.Order) error)
+	ListDLQMessages(ctx context.Context, limit int) ([]DLQMessage, error)
+	ReplayDLQMessage(ctx context.Context, partition int, offset int64) error
 	Close() error
 	EnsureTopicsExist() error
 }
+
+// Message is a message published with PublishBatch; Value is encoded as JSON
+type Message struct {
+	Topic string
+	Key   string
+	Value interface{}
+	// Delivered, if set, is called once with the outcome of the message: before
+	// PublishBatch returns, or when the broker acknowledges it if the producer is async
+	Delivered func(err error)
+}
+
+// BatchError reports the per-message outcome of a partially failed PublishBatch.
+// It holds one entry per message, nil for messages that were published.
+type BatchError []error
+
+// Error implements the error interface
+func (e BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d messages failed to publish: %v", failed, len(e), first)
+}
+
+// errOrNil returns e if any message failed, nil otherwise
+func (e BatchError) errOrNil() error {
+	for _, err := range e {
+		if err != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestCommitTrackerCommitsInOffsetOrder(t *testing.T) {
+	commits := newCommitTracker()
+	var committed []kafka.Message
+	commit := func(last kafka.Message) error {
+		committed = append(committed, last)
+		return nil
+	}
+
+	first := commits.track(kafka.Message{Partition: 0, Offset: 10})
+	second := commits.track(kafka.Message{Partition: 0, Offset: 11})
+	other := commits.track(kafka.Message{Partition: 1, Offset: 5})
+	third := commits.track(kafka.Message{Partition: 0, Offset: 12})
+
+	// A later message finishing first must not commit past the one still running
+	if err := commits.complete(second, commit); err != nil {
+		t.Fatal(err)
+	}
+	if len(committed) != 0 {
+		t.Fatalf("expected no commit while offset 10 is in flight, got %v", committed)
+	}
+
+	// Partitions are committed independently
+	if err := commits.complete(other, commit); err != nil {
+		t.Fatal(err)
+	}
+	// Finishing the head commits the whole run of done messages in one go
+	if err := commits.complete(first, commit); err != nil {
+		t.Fatal(err)
+	}
+	if err := commits.complete(third, commit); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		partition int
+		offset    int64
+	}{{1, 5}, {0, 11}, {0, 12}}
+	if len(committed) != len(want) {
+		t.Fatalf("expected %d commits, got %d", len(want), len(committed))
+	}
+	for i, w := range want {
+		if committed[i].Partition != w.partition || committed[i].Offset != w.offset {
+			t.Errorf("commit %d: got %d/%d, want %d/%d",
+				i, committed[i].Partition, committed[i].Offset, w.partition, w.offset)
+		}
+	}
+}
@@ -2,34 +2,55 @@ package messaging
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
-// MockKafkaPool is a no-op implementation for development when Kafka is unavailable
+// MockKafkaPool is an in-memory implementation for development when Kafka is unavailable.
+// With loopback enabled, messages published to the orders topic are delivered to
+// the ConsumeOrderEvents handler in-process.
 type MockKafkaPool struct {
 	logger   *zap.Logger
 	topics   config.KafkaTopicsConfig
+	retry    config.RetryConfig
+	parallel int
+	codec    *eventCodec
 	mu       sync.Mutex
 	messages []map[string]interface{}
+	dlq      []DLQMessage
+	loopback chan kafka.Message
 	wg       sync.WaitGroup
 	ctx      context.Context
 	cancel   context.CancelFunc
 }
 
 // NewMockKafkaPool creates a mock Kafka pool for development
-func NewMockKafkaPool(cfg *config.KafkaConfig, logger *zap.Logger) *MockKafkaPool {
+func NewMockKafkaPool(cfg *config.KafkaConfig, logger *zap.Logger) (*MockKafkaPool, error) {
+	codec, err := newEventCodec(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize event codec: %w", err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	return &MockKafkaPool{
-		logger: logger,
-		topics: cfg.Topics,
-		ctx:    ctx,
-		cancel: cancel,
+	mkp := &MockKafkaPool{
+		logger:   logger,
+		topics:   cfg.Topics,
+		retry:    cfg.Retry,
+		parallel: cfg.Concurrency(),
+		codec:    codec,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if cfg.MockLoopback {
+		mkp.loopback = make(chan kafka.Message, 1000)
 	}
+	return mkp, nil
 }
 
 // PublishOrderEvent publishes an order event (logs only in mock)
@@ -69,18 +90,144 @@ func (mkp *MockKafkaPool) PublishGenericEvent(ctx context.Context, topic string,
 		zap.String("key", key),
 	)
 
+	if topic == mkp.topics.Orders && mkp.loopback != nil {
+		msg, err := mkp.codec.encode(topic, key, value)
+		if err != nil {
+			return err
+		}
+		return mkp.deliver(msg)
+	}
 	return nil
 }
 
-// ConsumeOrderEvents is a no-op in mock
+// PublishBatch publishes each message as a generic event
+func (mkp *MockKafkaPool) PublishBatch(ctx context.Context, messages []Message) error {
+	errs := make(BatchError, len(messages))
+	for i, m := range messages {
+		errs[i] = mkp.PublishGenericEvent(ctx, m.Topic, m.Key, m.Value)
+		if m.Delivered != nil {
+			m.Delivered(errs[i])
+		}
+	}
+	return errs.errOrNil()
+}
+
+// deliver queues a message for the in-process consumer when loopback is enabled
+func (mkp *MockKafkaPool) deliver(msg kafka.Message) error {
+	if mkp.loopback == nil {
+		return nil
+	}
+
+	select {
+	case mkp.loopback <- msg:
+		return nil
+	default:
+		return fmt.Errorf("mock loopback buffer full")
+	}
+}
+
+// ConsumeOrderEvents delivers looped-back orders to handler; without loopback it is a no-op.
+// Like KafkaPool, it handles up to the configured consumer concurrency of orders at once,
+// retries failed deliveries and then keeps them in an in-memory DLQ.
 func (mkp *MockKafkaPool) ConsumeOrderEvents(handler func(*domain.Order) error) {
+	slots := make(chan struct{}, mkp.parallel)
+
 	mkp.wg.Add(1)
 	go func() {
 		defer mkp.wg.Done()
-		<-mkp.ctx.Done()
+		for {
+			var msg kafka.Message
+			select {
+			case <-mkp.ctx.Done():
+				return
+			case msg = <-mkp.loopback:
+			}
+
+			select {
+			case <-mkp.ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+			mkp.wg.Add(1)
+			go func() {
+				defer mkp.wg.Done()
+				defer func() { <-slots }()
+				mkp.consumeMessage(msg, handler)
+			}()
+		}
 	}()
 }
 
+// consumeMessage decodes and handles a looped-back message under the retry policy
+func (mkp *MockKafkaPool) consumeMessage(msg kafka.Message, handler func(*domain.Order) error) {
+	order, err := mkp.codec.decodeOrder(msg)
+	if err != nil {
+		mkp.deadLetter(msg, 1, err)
+		return
+	}
+	if order == nil {
+		return
+	}
+
+	attempts, err := retryWithBackoff(mkp.ctx, mkp.retry, func() error {
+		return handler(order)
+	})
+	if err != nil && mkp.ctx.Err() == nil {
+		mkp.deadLetter(msg, attempts, err)
+	}
+}
+
+// deadLetter keeps a failed message in the in-memory DLQ
+func (mkp *MockKafkaPool) deadLetter(msg kafka.Message, attempts int, cause error) {
+	mkp.mu.Lock()
+	defer mkp.mu.Unlock()
+
+	dlq := newDLQMessage(kafka.Message{Offset: int64(len(mkp.dlq)), Key: msg.Key, Value: msg.Value})
+	dlq.Headers = msg.Headers
+	dlq.Error = cause.Error()
+	dlq.Attempts = attempts
+	dlq.OriginalTopic = mkp.topics.Orders
+	dlq.FailedAt = time.Now()
+	mkp.dlq = append(mkp.dlq, dlq)
+
+	mkp.logger.Error("Mock: order message moved to DLQ",
+		zap.Int("attempts", attempts),
+		zap.Error(cause),
+	)
+}
+
+// ListDLQMessages returns up to limit of the most recent in-memory DLQ messages
+func (mkp *MockKafkaPool) ListDLQMessages(ctx context.Context, limit int) ([]DLQMessage, error) {
+	mkp.mu.Lock()
+	defer mkp.mu.Unlock()
+
+	start := 0
+	if limit > 0 && len(mkp.dlq) > limit {
+		start = len(mkp.dlq) - limit
+	}
+	messages := make([]DLQMessage, len(mkp.dlq)-start)
+	copy(messages, mkp.dlq[start:])
+	return messages, nil
+}
+
+// ReplayDLQMessage redelivers an in-memory DLQ message to the consumer
+func (mkp *MockKafkaPool) ReplayDLQMessage(ctx context.Context, partition int, offset int64) error {
+	mkp.mu.Lock()
+	defer mkp.mu.Unlock()
+
+	if partition != 0 || offset < 0 || offset >= int64(len(mkp.dlq)) {
+		return fmt.Errorf("DLQ message %d/%d not found", partition, offset)
+	}
+	dlq := mkp.dlq[offset]
+	return mkp.deliver(kafka.Message{
+		Topic:   mkp.topics.Orders,
+		Key:     []byte(dlq.Key),
+		Value:   dlq.Value,
+		Headers: dlq.Headers,
+		Time:    time.Now(),
+	})
+}
+
 // Close closes the mock pool
 func (mkp *MockKafkaPool) Close() error {
 	mkp.cancel()
@@ -0,0 +1,243 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// FieldType is the type of an event schema field
+type FieldType string
+
+// Field types supported by the serializers
+const (
+	FieldString    FieldType = "string"
+	FieldDouble    FieldType = "double"
+	FieldLong      FieldType = "long"
+	FieldTimestamp FieldType = "timestamp"
+)
+
+// SchemaField is a field of an event schema. Number is the stable field number
+// used by the Protobuf serializer; optional fields default to their zero value
+// when absent.
+type SchemaField struct {
+	Name     string    `json:"name"`
+	Number   int       `json:"number"`
+	Type     FieldType `json:"type"`
+	Optional bool      `json:"optional,omitempty"`
+}
+
+// EventSchema is a versioned, flat schema of an event payload
+type EventSchema struct {
+	Name    string        `json:"name"`
+	Version int           `json:"version"`
+	Fields  []SchemaField `json:"fields"`
+}
+
+// URI returns the schema reference carried in the CloudEvents dataschema attribute
+func (s *EventSchema) URI() string {
+	return fmt.Sprintf("/schemas/%s/v%d", s.Name, s.Version)
+}
+
+// parseSchemaURI splits a dataschema reference into schema name and version
+func parseSchemaURI(uri string) (string, int, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "/schemas/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "v") {
+		return "", 0, fmt.Errorf("invalid dataschema %q", uri)
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid dataschema %q", uri)
+	}
+	return parts[0], version, nil
+}
+
+// AvroSchema returns the Avro record schema matching the Avro serializer's encoding
+func (s *EventSchema) AvroSchema() string {
+	type avroField struct {
+		Name    string      `json:"name"`
+		Type    interface{} `json:"type"`
+		Default interface{} `json:"default,omitempty"`
+	}
+
+	fields := make([]avroField, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		af := avroField{Name: f.Name}
+		switch f.Type {
+		case FieldString:
+			af.Type = "string"
+		case FieldDouble:
+			af.Type = "double"
+		case FieldLong:
+			af.Type = "long"
+		case FieldTimestamp:
+			af.Type = map[string]string{"type": "long", "logicalType": "timestamp-millis"}
+		}
+		if f.Optional {
+			af.Default = zeroValue(f.Type)
+			if f.Type == FieldTimestamp {
+				af.Default = 0
+			}
+		}
+		fields = append(fields, af)
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":      "record",
+		"name":      fmt.Sprintf("%sV%d", s.Name, s.Version),
+		"namespace": strings.TrimSuffix(CloudEventTypePrefix, "."),
+		"fields":    fields,
+	})
+	return string(data)
+}
+
+// SchemaFromType derives an event schema from a payload struct. Field names come
+// from the json tags and field numbers from the event tags ("N" or "N,optional");
+// embedded structs are flattened. Decimals are strings, so they keep every digit.
+func SchemaFromType(name string, version int, payload interface{}) (*EventSchema, error) {
+	schema := &EventSchema{Name: name, Version: version}
+	if err := appendSchemaFields(schema, reflect.TypeOf(payload)); err != nil {
+		return nil, fmt.Errorf("invalid %s v%d payload: %w", name, version, err)
+	}
+	return schema, nil
+}
+
+// appendSchemaFields adds the fields of a struct type to schema
+func appendSchemaFields(schema *EventSchema, t reflect.Type) error {
+	timeType := reflect.TypeOf(time.Time{})
+	decimalType := reflect.TypeOf(decimal.Decimal{})
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := appendSchemaFields(schema, sf.Type); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := sf.Tag.Get("event")
+		if tag == "" {
+			return fmt.Errorf("field %s has no event tag", sf.Name)
+		}
+		opts := strings.Split(tag, ",")
+		number, err := strconv.Atoi(opts[0])
+		if err != nil || number <= 0 {
+			return fmt.Errorf("field %s has invalid field number %q", sf.Name, opts[0])
+		}
+
+		field := SchemaField{
+			Name:     strings.Split(sf.Tag.Get("json"), ",")[0],
+			Number:   number,
+			Optional: len(opts) > 1 && opts[1] == "optional",
+		}
+		switch {
+		case sf.Type == timeType:
+			field.Type = FieldTimestamp
+		case sf.Type == decimalType:
+			field.Type = FieldString
+		case sf.Type.Kind() == reflect.String:
+			field.Type = FieldString
+		case sf.Type.Kind() == reflect.Float64:
+			field.Type = FieldDouble
+		case sf.Type.Kind() == reflect.Int, sf.Type.Kind() == reflect.Int64:
+			field.Type = FieldLong
+		default:
+			return fmt.Errorf("field %s has unsupported type %s", sf.Name, sf.Type)
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	return nil
+}
+
+// BuiltinSchemas returns the schemas of every event version this service publishes,
+// oldest version first so each is registered after the one it must stay compatible with
+func BuiltinSchemas() ([]*EventSchema, error) {
+	versions := make([]int, 0, len(domain.EventPayloads))
+	for version := range domain.EventPayloads {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	var schemas []*EventSchema
+	for _, version := range versions {
+		for eventType, payload := range domain.EventPayloads[version] {
+			schema, err := SchemaFromType(eventType, version, payload)
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas, nil
+}
+
+// Record is an event payload keyed by schema field name, holding string, float64,
+// int64 and time.Time values
+type Record map[string]interface{}
+
+// recordFromJSON converts a JSON payload into a record typed by schema. Missing
+// optional fields take their zero value; missing required fields are an error.
+func recordFromJSON(schema *EventSchema, payload []byte) (Record, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	record := make(Record, len(schema.Fields))
+	for _, f := range schema.Fields {
+		value, ok := raw[f.Name]
+		if !ok || string(value) == "null" {
+			if !f.Optional {
+				return nil, fmt.Errorf("%s v%d: missing required field %s", schema.Name, schema.Version, f.Name)
+			}
+			record[f.Name] = zeroValue(f.Type)
+			continue
+		}
+
+		var err error
+		switch f.Type {
+		case FieldString:
+			var v string
+			err = json.Unmarshal(value, &v)
+			record[f.Name] = v
+		case FieldDouble:
+			var v float64
+			err = json.Unmarshal(value, &v)
+			record[f.Name] = v
+		case FieldLong:
+			var v int64
+			err = json.Unmarshal(value, &v)
+			record[f.Name] = v
+		case FieldTimestamp:
+			var v time.Time
+			err = json.Unmarshal(value, &v)
+			record[f.Name] = v
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s v%d: invalid field %s: %w", schema.Name, schema.Version, f.Name, err)
+		}
+	}
+	return record, nil
+}
+
+// zeroValue returns the default of an absent optional field
+func zeroValue(t FieldType) interface{} {
+	switch t {
+	case FieldString:
+		return ""
+	case FieldDouble:
+		return float64(0)
+	case FieldTimestamp:
+		return time.UnixMilli(0).UTC()
+	default:
+		return int64(0)
+	}
+}
@@ -0,0 +1,172 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+// ErrIncompatibleSchema is returned when a schema would break existing consumers
+var ErrIncompatibleSchema = errors.New("incompatible event schema")
+
+// SchemaRegistry is a local stand-in for a schema registry. Schemas are kept per
+// name and version and, when a directory is configured, persisted as JSON files so
+// that changes to a published schema are caught on the next start.
+type SchemaRegistry struct {
+	dir     string
+	mu      sync.RWMutex
+	schemas map[string]map[int]*EventSchema
+}
+
+// NewSchemaRegistry creates a schema registry, loading any schemas stored in dir.
+// An empty dir keeps schemas in memory only.
+func NewSchemaRegistry(dir string) (*SchemaRegistry, error) {
+	r := &SchemaRegistry{
+		dir:     dir,
+		schemas: make(map[string]map[int]*EventSchema),
+	}
+	if dir == "" {
+		return r, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", file, err)
+		}
+		var schema EventSchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("failed to decode schema %s: %w", file, err)
+		}
+		r.put(&schema)
+	}
+	return r, nil
+}
+
+// Register adds a schema version. A version that is already registered must be
+// unchanged, and a new version must be backward compatible with the latest
+// earlier version: consumers on the new schema must still read older events.
+func (r *SchemaRegistry) Register(schema *EventSchema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.schemas[schema.Name][schema.Version]; ok {
+		if !reflect.DeepEqual(existing.Fields, schema.Fields) {
+			return fmt.Errorf("%w: %s v%d changed after it was registered; add a new version instead",
+				ErrIncompatibleSchema, schema.Name, schema.Version)
+		}
+		return nil
+	}
+
+	if previous := r.latestBefore(schema.Name, schema.Version); previous != nil {
+		if err := checkBackwardCompatible(previous, schema); err != nil {
+			return err
+		}
+	}
+
+	if r.dir != "" {
+		if err := r.store(schema); err != nil {
+			return err
+		}
+	}
+	r.put(schema)
+	return nil
+}
+
+// Get returns a registered schema version
+func (r *SchemaRegistry) Get(name string, version int) (*EventSchema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[name][version]
+	if !ok {
+		return nil, fmt.Errorf("schema %s v%d not registered", name, version)
+	}
+	return schema, nil
+}
+
+// Latest returns the newest registered version of a schema
+func (r *SchemaRegistry) Latest(name string) (*EventSchema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema := r.latestBefore(name, math.MaxInt)
+	if schema == nil {
+		return nil, fmt.Errorf("schema %s not registered", name)
+	}
+	return schema, nil
+}
+
+// latestBefore returns the newest version of a schema older than version
+func (r *SchemaRegistry) latestBefore(name string, version int) *EventSchema {
+	var latest *EventSchema
+	for v, schema := range r.schemas[name] {
+		if v < version && (latest == nil || v > latest.Version) {
+			latest = schema
+		}
+	}
+	return latest
+}
+
+// put indexes a schema
+func (r *SchemaRegistry) put(schema *EventSchema) {
+	if r.schemas[schema.Name] == nil {
+		r.schemas[schema.Name] = make(map[int]*EventSchema)
+	}
+	r.schemas[schema.Name][schema.Version] = schema
+}
+
+// store writes a schema to the registry directory
+func (r *SchemaRegistry) store(schema *EventSchema) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create schema directory: %w", err)
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	file := filepath.Join(r.dir, fmt.Sprintf("%s.v%d.json", schema.Name, schema.Version))
+	if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write schema %s: %w", file, err)
+	}
+	return nil
+}
+
+// checkBackwardCompatible reports whether next can read events written with prev.
+// Fields may be removed, and added only as optional; a kept field must keep its
+// type and number, and a number may not be reused by another field.
+func checkBackwardCompatible(prev, next *EventSchema) error {
+	prevByName := make(map[string]SchemaField, len(prev.Fields))
+	prevByNumber := make(map[int]SchemaField, len(prev.Fields))
+	for _, f := range prev.Fields {
+		prevByName[f.Name] = f
+		prevByNumber[f.Number] = f
+	}
+
+	for _, f := range next.Fields {
+		old, existed := prevByName[f.Name]
+		switch {
+		case !existed && !f.Optional:
+			return fmt.Errorf("%w: %s v%d adds required field %s", ErrIncompatibleSchema, next.Name, next.Version, f.Name)
+		case existed && old.Type != f.Type:
+			return fmt.Errorf("%w: %s v%d changes the type of %s from %s to %s",
+				ErrIncompatibleSchema, next.Name, next.Version, f.Name, old.Type, f.Type)
+		case existed && old.Number != f.Number:
+			return fmt.Errorf("%w: %s v%d renumbers field %s", ErrIncompatibleSchema, next.Name, next.Version, f.Name)
+		}
+		if other, taken := prevByNumber[f.Number]; taken && other.Name != f.Name {
+			return fmt.Errorf("%w: %s v%d reuses field number %d of %s",
+				ErrIncompatibleSchema, next.Name, next.Version, f.Number, other.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package messaging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// newDialer builds the dialer used by the reader and admin connections, applying
+// the TLS and SASL settings
+func newDialer(cfg *config.KafkaConfig) (*kafka.Dialer, error) {
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := newSASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}, nil
+}
+
+// newTransport builds the writer transport with the same security settings as dialer
+func newTransport(dialer *kafka.Dialer) *kafka.Transport {
+	return &kafka.Transport{
+		DialTimeout: dialer.Timeout,
+		TLS:         dialer.TLS,
+		SASL:        dialer.SASLMechanism,
+	}
+}
+
+// newTLSConfig returns the client TLS configuration, or nil when TLS is disabled
+func newTLSConfig(cfg config.KafkaTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in Kafka CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newSASLMechanism returns the configured SASL mechanism, or nil when SASL is disabled
+func newSASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Mechanism {
+	case "":
+		return nil, nil
+	case config.SASLPlain:
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case config.SASLScramSHA256:
+		return newScramMechanism(scram.SHA256, cfg)
+	case config.SASLScramSHA512:
+		return newScramMechanism(scram.SHA512, cfg)
+	}
+	return nil, fmt.Errorf("unknown SASL mechanism %q", cfg.Mechanism)
+}
+
+// newScramMechanism returns a SCRAM mechanism; the credentials are SASLprepped and
+// rejected here if they contain prohibited characters
+func newScramMechanism(algo scram.Algorithm, cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	mechanism, err := scram.Mechanism(algo, cfg.Username, cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s credentials: %w", cfg.Mechanism, err)
+	}
+	return mechanism, nil
+}
@@ -0,0 +1,253 @@
+package messaging
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Serialization formats
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+// Content types of the serialization formats, used as the CloudEvents datacontenttype
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+var errTruncated = errors.New("truncated payload")
+
+// Serializer encodes event records according to their schema
+type Serializer interface {
+	ContentType() string
+	Serialize(schema *EventSchema, record Record) ([]byte, error)
+	Deserialize(schema *EventSchema, data []byte) (Record, error)
+}
+
+// NewSerializer returns the serializer for a format; an empty format means JSON
+func NewSerializer(format string) (Serializer, error) {
+	switch format {
+	case "", FormatJSON:
+		return JSONSerializer{}, nil
+	case FormatProtobuf:
+		return ProtobufSerializer{}, nil
+	case FormatAvro:
+		return AvroSerializer{}, nil
+	}
+	return nil, fmt.Errorf("unknown serialization format %q", format)
+}
+
+// serializerForContentType returns the serializer that produced a content type
+func serializerForContentType(contentType string) (Serializer, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return JSONSerializer{}, nil
+	case ContentTypeProtobuf:
+		return ProtobufSerializer{}, nil
+	case ContentTypeAvro:
+		return AvroSerializer{}, nil
+	}
+	return nil, fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// JSONSerializer encodes records as JSON objects
+type JSONSerializer struct{}
+
+// ContentType implements Serializer
+func (JSONSerializer) ContentType() string { return ContentTypeJSON }
+
+// Serialize implements Serializer
+func (JSONSerializer) Serialize(schema *EventSchema, record Record) ([]byte, error) {
+	return json.Marshal(record)
+}
+
+// Deserialize implements Serializer
+func (JSONSerializer) Deserialize(schema *EventSchema, data []byte) (Record, error) {
+	return recordFromJSON(schema, data)
+}
+
+// ProtobufSerializer encodes records in the Protobuf wire format, using the schema
+// field numbers. Strings are length-delimited, doubles fixed64, and longs and
+// timestamps (Unix milliseconds) varints, matching proto3 string, double and int64.
+type ProtobufSerializer struct{}
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// ContentType implements Serializer
+func (ProtobufSerializer) ContentType() string { return ContentTypeProtobuf }
+
+// Serialize implements Serializer. Zero values are omitted as in proto3.
+func (ProtobufSerializer) Serialize(schema *EventSchema, record Record) ([]byte, error) {
+	var buf []byte
+	for _, f := range schema.Fields {
+		switch v := record[f.Name].(type) {
+		case string:
+			if v != "" {
+				buf = binary.AppendUvarint(buf, uint64(f.Number)<<3|protoBytes)
+				buf = binary.AppendUvarint(buf, uint64(len(v)))
+				buf = append(buf, v...)
+			}
+		case float64:
+			if v != 0 {
+				buf = binary.AppendUvarint(buf, uint64(f.Number)<<3|protoFixed64)
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+			}
+		case int64:
+			if v != 0 {
+				buf = binary.AppendUvarint(buf, uint64(f.Number)<<3|protoVarint)
+				buf = binary.AppendUvarint(buf, uint64(v))
+			}
+		case time.Time:
+			if ms := v.UnixMilli(); ms != 0 {
+				buf = binary.AppendUvarint(buf, uint64(f.Number)<<3|protoVarint)
+				buf = binary.AppendUvarint(buf, uint64(ms))
+			}
+		default:
+			return nil, fmt.Errorf("%s v%d: field %s has unsupported value %T", schema.Name, schema.Version, f.Name, v)
+		}
+	}
+	return buf, nil
+}
+
+// Deserialize implements Serializer. Unknown fields are skipped and absent fields
+// take their zero value.
+func (ProtobufSerializer) Deserialize(schema *EventSchema, data []byte) (Record, error) {
+	byNumber := make(map[int]SchemaField, len(schema.Fields))
+	record := make(Record, len(schema.Fields))
+	for _, f := range schema.Fields {
+		byNumber[f.Number] = f
+		record[f.Name] = zeroValue(f.Type)
+	}
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		data = data[n:]
+		number, wireType := int(tag>>3), tag&7
+
+		var value uint64
+		var bytes []byte
+		switch wireType {
+		case protoVarint:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return nil, errTruncated
+			}
+			value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, errTruncated
+			}
+			bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case protoFixed32:
+			if len(data) < 4 {
+				return nil, errTruncated
+			}
+			data = data[4:]
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", wireType)
+		}
+
+		f, ok := byNumber[number]
+		if !ok {
+			continue
+		}
+		switch f.Type {
+		case FieldString:
+			record[f.Name] = string(bytes)
+		case FieldDouble:
+			record[f.Name] = math.Float64frombits(value)
+		case FieldLong:
+			record[f.Name] = int64(value)
+		case FieldTimestamp:
+			record[f.Name] = time.UnixMilli(int64(value)).UTC()
+		}
+	}
+	return record, nil
+}
+
+// AvroSerializer encodes records in the Avro binary encoding of
+// EventSchema.AvroSchema. Readers need the writer schema, which is identified by
+// the CloudEvents dataschema attribute.
+type AvroSerializer struct{}
+
+// ContentType implements Serializer
+func (AvroSerializer) ContentType() string { return ContentTypeAvro }
+
+// Serialize implements Serializer
+func (AvroSerializer) Serialize(schema *EventSchema, record Record) ([]byte, error) {
+	var buf []byte
+	for _, f := range schema.Fields {
+		switch v := record[f.Name].(type) {
+		case string:
+			buf = binary.AppendVarint(buf, int64(len(v)))
+			buf = append(buf, v...)
+		case float64:
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		case int64:
+			buf = binary.AppendVarint(buf, v)
+		case time.Time:
+			buf = binary.AppendVarint(buf, v.UnixMilli())
+		default:
+			return nil, fmt.Errorf("%s v%d: field %s has unsupported value %T", schema.Name, schema.Version, f.Name, v)
+		}
+	}
+	return buf, nil
+}
+
+// Deserialize implements Serializer
+func (AvroSerializer) Deserialize(schema *EventSchema, data []byte) (Record, error) {
+	record := make(Record, len(schema.Fields))
+	for _, f := range schema.Fields {
+		switch f.Type {
+		case FieldString:
+			length, n := binary.Varint(data)
+			if n <= 0 || length < 0 || int64(len(data)-n) < length {
+				return nil, errTruncated
+			}
+			record[f.Name] = string(data[n : n+int(length)])
+			data = data[n+int(length):]
+		case FieldDouble:
+			if len(data) < 8 {
+				return nil, errTruncated
+			}
+			record[f.Name] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		case FieldLong, FieldTimestamp:
+			v, n := binary.Varint(data)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			data = data[n:]
+			if f.Type == FieldTimestamp {
+				record[f.Name] = time.UnixMilli(v).UTC()
+			} else {
+				record[f.Name] = v
+			}
+		}
+	}
+	return record, nil
+}
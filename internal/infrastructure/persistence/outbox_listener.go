@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OutboxNotifyChannel is the Postgres channel notified on every outbox insert
+const OutboxNotifyChannel = "outbox_events"
+
+// ListenOutboxEvents runs one LISTEN session on a dedicated connection and calls
+// notify for every outbox insert. notify is also called once the session is
+// established, so callers can catch up on events inserted while disconnected.
+// It returns when ctx is cancelled or the connection fails; callers reconnect by
+// calling it again.
+func (r *PostgresRepository) ListenOutboxEvents(ctx context.Context, notify func()) error {
+	conn, err := pgx.Connect(ctx, r.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open listen connection: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+OutboxNotifyChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", OutboxNotifyChannel, err)
+	}
+	notify()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to wait for outbox notification: %w", err)
+		}
+		notify()
+	}
+}
@@ -2,25 +2,31 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ivan-salazar14/nexus-order-manager/internal/config"
 	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 // PostgresRepository handles database operations
 type PostgresRepository struct {
-	db *gorm.DB
+	db  *gorm.DB
+	dsn string
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository
 func NewPostgresRepository(cfg *config.DatabaseConfig) (*PostgresRepository, error) {
 	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -35,26 +41,40 @@ func NewPostgresRepository(cfg *config.DatabaseConfig) (*PostgresRepository, err
 	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	return &PostgresRepository{db: db}, nil
+	return &PostgresRepository{db: db, dsn: cfg.DSN()}, nil
 }
 
 // AutoMigrate runs database migrations
 func (r *PostgresRepository) AutoMigrate() error {
-	return r.db.AutoMigrate(
+	err := r.db.AutoMigrate(
 		&domain.Order{},
 		&domain.OutboxEvent{},
+		&domain.ArchivedOutboxEvent{},
+		&domain.Fill{},
+		&domain.IdempotencyKey{},
+		&domain.KillSwitch{},
 	)
+	if err != nil {
+		return err
+	}
+
+	// Seed the shared kill switch row released, so toggles always have a row to lock
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.KillSwitch{ID: domain.KillSwitchID}).Error
 }
 
 // CreateOrder creates a new order in the database
 func (r *PostgresRepository) CreateOrder(ctx context.Context, order *domain.Order) error {
-	return r.db.WithContext(ctx).Create(order).Error
+	return r.unit(ctx).CreateOrder(order)
 }
 
 // GetOrder retrieves an order by ID
 func (r *PostgresRepository) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
 	var order domain.Order
 	err := r.db.WithContext(ctx).First(&order, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +83,17 @@ func (r *PostgresRepository) GetOrder(ctx context.Context, id string) (*domain.O
 
 // UpdateOrder updates an existing order
 func (r *PostgresRepository) UpdateOrder(ctx context.Context, order *domain.Order) error {
-	return r.db.WithContext(ctx).Save(order).Error
+	return r.unit(ctx).UpdateOrder(order)
+}
+
+// GetFillsByOrderID retrieves the fills of an order in execution order
+func (r *PostgresRepository) GetFillsByOrderID(ctx context.Context, orderID string) ([]*domain.Fill, error) {
+	var fills []*domain.Fill
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("trade_id ASC").
+		Find(&fills).Error
+	return fills, err
 }
 
 // ListOrders retrieves orders with optional filters
@@ -83,34 +113,186 @@ func (r *PostgresRepository) ListOrders(ctx context.Context, status domain.Order
 	return orders, err
 }
 
+// ListStaleOrders retrieves orders in the given statuses that have not been updated since before
+func (r *PostgresRepository) ListStaleOrders(ctx context.Context, statuses []domain.OrderStatus, before time.Time, limit int) ([]*domain.Order, error) {
+	var orders []*domain.Order
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", statuses, before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
 // CreateOutboxEvent creates a new outbox event
 func (r *PostgresRepository) CreateOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
-	return r.db.WithContext(ctx).Create(event).Error
+	return r.unit(ctx).CreateOutboxEvent(event)
 }
 
-// GetUnprocessedOutboxEvents retrieves unprocessed events
-func (r *PostgresRepository) GetUnprocessedOutboxEvents(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+// claimOutboxEventsSQL leases due events to a relay. Only the oldest unprocessed event
+// of each aggregate is eligible, which keeps per-aggregate ordering across relays,
+// and SKIP LOCKED lets concurrent relays claim disjoint rows without waiting.
+const claimOutboxEventsSQL = `
+UPDATE outbox_events SET locked_by = ?, locked_until = ?
+WHERE id IN (
+	SELECT e.id FROM outbox_events e
+	WHERE e.processed = false AND e.quarantined = false
+		AND (e.next_attempt_at IS NULL OR e.next_attempt_at <= ?)
+		AND (e.locked_until IS NULL OR e.locked_until <= ?)
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox_events prev
+			WHERE prev.aggregate = e.aggregate AND prev.aggregate_id = e.aggregate_id
+				AND prev.processed = false AND prev.id < e.id
+		)
+	ORDER BY e.id
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+// ClaimOutboxEvents leases up to limit events that are due for publishing to owner.
+// Quarantined events, events still backing off and events leased to another relay are skipped.
+func (r *PostgresRepository) ClaimOutboxEvents(ctx context.Context, owner string, lease time.Duration, limit int) ([]*domain.OutboxEvent, error) {
+	now := time.Now()
 	var events []*domain.OutboxEvent
 	err := r.db.WithContext(ctx).
-		Where("processed = ?", false).
-		Order("created_at ASC").
-		Limit(limit).
-		Find(&events).Error
-	return events, err
+		Raw(claimOutboxEventsSQL, owner, now.Add(lease), now, now, limit).
+		Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
 }
 
-// MarkOutboxEventProcessed marks an outbox event as processed
-func (r *PostgresRepository) MarkOutboxEventProcessed(ctx context.Context, id uint64) error {
+// MarkOutboxEventsProcessed marks outbox events as processed
+func (r *PostgresRepository) MarkOutboxEventsProcessed(ctx context.Context, ids []uint64) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).
 		Model(&domain.OutboxEvent{}).
-		Where("id = ?", id).
+		Where("id IN ?", ids).
 		Updates(map[string]interface{}{
 			"processed":    true,
 			"processed_at": now,
+			"locked_by":    "",
+			"locked_until": nil,
 		}).Error
 }
 
+// RecordOutboxEventFailure stores the retry state of an event after a failed publish
+func (r *PostgresRepository) RecordOutboxEventFailure(ctx context.Context, event *domain.OutboxEvent) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("id = ?", event.ID).
+		Updates(map[string]interface{}{
+			"attempts":        event.Attempts,
+			"last_error":      event.LastError,
+			"next_attempt_at": event.NextAttemptAt,
+			"quarantined":     event.Quarantined,
+			"quarantined_at":  event.QuarantinedAt,
+			"locked_by":       "",
+			"locked_until":    nil,
+		}).Error
+}
+
+// ListQuarantinedOutboxEvents retrieves quarantined events, oldest first
+func (r *PostgresRepository) ListQuarantinedOutboxEvents(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	var events []*domain.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Where("processed = ? AND quarantined = ?", false, true).
+		Order("quarantined_at ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// RequeueOutboxEvent releases a quarantined event for immediate publishing with a fresh attempt count.
+// It returns domain.ErrOutboxEventNotFound if no quarantined event has the given ID.
+func (r *PostgresRepository) RequeueOutboxEvent(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("id = ? AND processed = ? AND quarantined = ?", id, false, true).
+		Updates(map[string]interface{}{
+			"attempts":        0,
+			"next_attempt_at": nil,
+			"quarantined":     false,
+			"quarantined_at":  nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrOutboxEventNotFound
+	}
+	return nil
+}
+
+// PurgeProcessedOutboxEvents deletes up to limit events processed before the given time,
+// copying them to the archive table first when archive is set. Rows locked by another
+// transaction are skipped so the janitor never blocks the relay.
+func (r *PostgresRepository) PurgeProcessedOutboxEvents(ctx context.Context, before time.Time, limit int, archive bool) (int64, error) {
+	batch := `DELETE FROM outbox_events WHERE id IN (
+	SELECT id FROM outbox_events
+	WHERE processed = true AND processed_at < ?
+	ORDER BY id
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)`
+	if !archive {
+		result := r.db.WithContext(ctx).Exec(batch, before, limit)
+		return result.RowsAffected, result.Error
+	}
+
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(&domain.OutboxEvent{}); err != nil {
+		return 0, fmt.Errorf("failed to parse outbox schema: %w", err)
+	}
+	columns := strings.Join(stmt.Schema.DBNames, ", ")
+
+	// Listing the columns keeps the copy correct even if the two tables order them differently
+	query := fmt.Sprintf(`WITH moved AS (%s RETURNING *)
+INSERT INTO outbox_events_archive (%s, archived_at)
+SELECT %s, ? FROM moved`, batch, columns, columns)
+
+	result := r.db.WithContext(ctx).Exec(query, before, limit, time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetIdempotencyKey retrieves an unexpired idempotency key, or nil if there is none
+func (r *PostgresRepository) GetIdempotencyKey(ctx context.Context, key string) (*domain.IdempotencyKey, error) {
+	var record domain.IdempotencyKey
+	err := r.db.WithContext(ctx).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveIdempotencyKey stores an idempotency key, replacing an expired record with the same key.
+// It returns domain.ErrIdempotencyConflict if an unexpired record already holds the key.
+func (r *PostgresRepository) SaveIdempotencyKey(ctx context.Context, record *domain.IdempotencyKey) error {
+	return r.unit(ctx).SaveIdempotencyKey(record)
+}
+
+// DeleteExpiredIdempotencyKeys removes idempotency keys past their retention window
+func (r *PostgresRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// GetKillSwitch returns the shared kill switch state
+func (r *PostgresRepository) GetKillSwitch(ctx context.Context) (*domain.KillSwitch, error) {
+	return r.unit(ctx).getKillSwitch(nil)
+}
+
 // WithTransaction executes operations within a transaction
 func (r *PostgresRepository) WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
 	return r.db.WithContext(ctx).Transaction(fn)
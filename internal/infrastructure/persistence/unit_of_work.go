@@ -0,0 +1,176 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ivan-salazar14/nexus-order-manager/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnitOfWork groups repository writes that must commit or roll back together
+type UnitOfWork struct {
+	tx *gorm.DB
+}
+
+// InTransaction runs fn inside a database transaction. Every write made through
+// the unit of work is rolled back if fn returns an error.
+func (r *PostgresRepository) InTransaction(ctx context.Context, fn func(uow *UnitOfWork) error) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		return fn(&UnitOfWork{tx: tx})
+	})
+}
+
+// unit returns a unit of work bound to the repository connection, outside any transaction
+func (r *PostgresRepository) unit(ctx context.Context) *UnitOfWork {
+	return &UnitOfWork{tx: r.db.WithContext(ctx)}
+}
+
+// CreateOrder creates a new order, returning domain.ErrDuplicateOrder if the ID is taken
+func (u *UnitOfWork) CreateOrder(order *domain.Order) error {
+	err := u.tx.Create(order).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.ErrDuplicateOrder
+	}
+	return err
+}
+
+// GetOrderForUpdate retrieves an order and locks its row until the transaction ends
+func (u *UnitOfWork) GetOrderForUpdate(id string) (*domain.Order, error) {
+	var order domain.Order
+	err := u.tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetKillSwitchForUpdate retrieves the kill switch and locks its row until the transaction ends
+func (u *UnitOfWork) GetKillSwitchForUpdate() (*domain.KillSwitch, error) {
+	return u.getKillSwitch(&clause.Locking{Strength: "UPDATE"})
+}
+
+// GetKillSwitchForShare retrieves the kill switch and keeps it from being toggled
+// until the transaction ends, while other transactions may still read it
+func (u *UnitOfWork) GetKillSwitchForShare() (*domain.KillSwitch, error) {
+	return u.getKillSwitch(&clause.Locking{Strength: "SHARE"})
+}
+
+// getKillSwitch retrieves the kill switch with an optional row lock. A missing row
+// reads as released.
+func (u *UnitOfWork) getKillSwitch(locking *clause.Locking) (*domain.KillSwitch, error) {
+	tx := u.tx
+	if locking != nil {
+		tx = tx.Clauses(*locking)
+	}
+
+	killSwitch := domain.KillSwitch{ID: domain.KillSwitchID}
+	err := tx.First(&killSwitch, "id = ?", domain.KillSwitchID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &killSwitch, nil
+}
+
+// SaveKillSwitch stores the kill switch state
+func (u *UnitOfWork) SaveKillSwitch(killSwitch *domain.KillSwitch) error {
+	return u.tx.Save(killSwitch).Error
+}
+
+// LockRiskScope takes an advisory lock on a risk scope, such as an account or a
+// symbol, held until the transaction ends
+func (u *UnitOfWork) LockRiskScope(scope string) error {
+	return u.tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "risk:"+scope).Error
+}
+
+// CountOrdersSince counts the orders submitted since the given time that were not
+// rejected, for one symbol or, when symbol is empty, for all symbols
+func (u *UnitOfWork) CountOrdersSince(symbol string, since time.Time) (int64, error) {
+	query := u.tx.Model(&domain.Order{}).
+		Where("created_at >= ? AND status <> ?", since, domain.StatusRejected)
+	if symbol != "" {
+		query = query.Where("symbol = ?", symbol)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// GetSymbolExposure sums the filled position of a symbol and the remaining quantity
+// of its open orders on each side
+func (u *UnitOfWork) GetSymbolExposure(symbol string) (*domain.Exposure, error) {
+	open := []domain.OrderStatus{domain.StatusPending, domain.StatusExecuting, domain.StatusPartiallyFilled}
+
+	var exposure domain.Exposure
+	err := u.tx.Model(&domain.Order{}).
+		Select(`COALESCE(SUM(CASE WHEN side = ? THEN executed_quantity ELSE -executed_quantity END), 0) AS net_position,
+			COALESCE(SUM(CASE WHEN side = ? AND status IN ? THEN quantity - executed_quantity ELSE 0 END), 0) AS open_buy,
+			COALESCE(SUM(CASE WHEN side = ? AND status IN ? THEN quantity - executed_quantity ELSE 0 END), 0) AS open_sell`,
+			domain.SideBuy, domain.SideBuy, open, domain.SideSell, open).
+		Where("symbol = ?", symbol).
+		Scan(&exposure).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exposure, nil
+}
+
+// UpdateOrder updates an existing order
+func (u *UnitOfWork) UpdateOrder(order *domain.Order) error {
+	return u.tx.Save(order).Error
+}
+
+// CreateFills stores fills, skipping those already recorded for the same order and trade ID
+func (u *UnitOfWork) CreateFills(fills []*domain.Fill) error {
+	if len(fills) == 0 {
+		return nil
+	}
+	return u.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&fills).Error
+}
+
+// CreateOutboxEvent creates a new outbox event and notifies listening relays.
+// Inside a transaction the notification is delivered on commit.
+func (u *UnitOfWork) CreateOutboxEvent(event *domain.OutboxEvent) error {
+	if err := u.tx.Create(event).Error; err != nil {
+		return err
+	}
+	return u.tx.Exec("SELECT pg_notify(?, ?)", OutboxNotifyChannel, strconv.FormatUint(event.ID, 10)).Error
+}
+
+// LastOutboxEventID returns the ID of the latest event of an aggregate, or 0 if it has none
+func (u *UnitOfWork) LastOutboxEventID(aggregate, aggregateID string) (uint64, error) {
+	var id uint64
+	err := u.tx.Model(&domain.OutboxEvent{}).
+		Select("COALESCE(MAX(id), 0)").
+		Where("aggregate = ? AND aggregate_id = ?", aggregate, aggregateID).
+		Scan(&id).Error
+	return id, err
+}
+
+// SaveIdempotencyKey stores an idempotency key, replacing an expired record with the same key.
+// It returns domain.ErrIdempotencyConflict if an unexpired record already holds the key.
+func (u *UnitOfWork) SaveIdempotencyKey(record *domain.IdempotencyKey) error {
+	result := u.tx.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "order_id", "created_at", "expires_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "expires_at"}, Value: time.Now()},
+			}},
+		}).
+		Create(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdempotencyConflict
+	}
+	return nil
+}